@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Report lists what changed between two snapshots
+type Report struct {
+	AddedArtists    []util.Artist
+	RemovedArtists  []util.Artist
+	AddedMembers    []memberChange
+	RemovedMembers  []memberChange
+	AddedConcerts   []concertKey
+	RemovedConcerts []concertKey
+
+	names map[int]string // artist ID -> name, from both snapshots
+}
+
+type memberChange struct {
+	ArtistID int
+	Member   string
+}
+
+// concertKey identifies a concert by artist, location and raw date
+type concertKey struct {
+	ArtistID int
+	Location string
+	Date     string
+}
+
+// diffSnapshots compares the artists and concerts of two sources
+func diffSnapshots(oldSource, newSource util.DataSource) (Report, error) {
+	oldArtists, err := oldSource.Artists()
+	if err != nil {
+		return Report{}, err
+	}
+	newArtists, err := newSource.Artists()
+	if err != nil {
+		return Report{}, err
+	}
+	oldRelations, err := oldSource.Relations()
+	if err != nil {
+		return Report{}, err
+	}
+	newRelations, err := newSource.Relations()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{names: make(map[int]string)}
+
+	oldByID := artistsByID(oldArtists)
+	newByID := artistsByID(newArtists)
+	for id, artist := range oldByID {
+		report.names[id] = artist.Name
+	}
+	for id, artist := range newByID {
+		report.names[id] = artist.Name
+	}
+
+	for id, artist := range newByID {
+		oldArtist, existed := oldByID[id]
+		if !existed {
+			report.AddedArtists = append(report.AddedArtists, artist)
+			continue
+		}
+
+		added, removed := diffStrings(oldArtist.Members, artist.Members)
+		for _, member := range added {
+			report.AddedMembers = append(report.AddedMembers, memberChange{ArtistID: id, Member: member})
+		}
+		for _, member := range removed {
+			report.RemovedMembers = append(report.RemovedMembers, memberChange{ArtistID: id, Member: member})
+		}
+	}
+	for id, artist := range oldByID {
+		if _, exists := newByID[id]; !exists {
+			report.RemovedArtists = append(report.RemovedArtists, artist)
+		}
+	}
+
+	oldConcerts := concertSet(oldRelations)
+	newConcerts := concertSet(newRelations)
+	for concert := range newConcerts {
+		if !oldConcerts[concert] {
+			report.AddedConcerts = append(report.AddedConcerts, concert)
+		}
+	}
+	for concert := range oldConcerts {
+		if !newConcerts[concert] {
+			report.RemovedConcerts = append(report.RemovedConcerts, concert)
+		}
+	}
+
+	report.sort()
+	return report, nil
+}
+
+func artistsByID(artists []util.Artist) map[int]util.Artist {
+	byID := make(map[int]util.Artist, len(artists))
+	for _, artist := range artists {
+		byID[artist.ID] = artist
+	}
+	return byID
+}
+
+// diffStrings returns the values only in b (added) and only in a (removed)
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func concertSet(relations util.RelationResponse) map[concertKey]bool {
+	set := make(map[concertKey]bool)
+	for _, relation := range relations.Index {
+		for location, dates := range relation.DatesLocations {
+			for _, date := range dates {
+				set[concertKey{ArtistID: relation.ID, Location: location, Date: date}] = true
+			}
+		}
+	}
+	return set
+}
+
+// sort orders every list so the output is stable
+func (r *Report) sort() {
+	sort.Slice(r.AddedArtists, func(i, j int) bool { return r.AddedArtists[i].ID < r.AddedArtists[j].ID })
+	sort.Slice(r.RemovedArtists, func(i, j int) bool { return r.RemovedArtists[i].ID < r.RemovedArtists[j].ID })
+	sortMembers(r.AddedMembers)
+	sortMembers(r.RemovedMembers)
+	sortConcerts(r.AddedConcerts)
+	sortConcerts(r.RemovedConcerts)
+}
+
+func sortMembers(changes []memberChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ArtistID != changes[j].ArtistID {
+			return changes[i].ArtistID < changes[j].ArtistID
+		}
+		return changes[i].Member < changes[j].Member
+	})
+}
+
+func sortConcerts(concerts []concertKey) {
+	sort.Slice(concerts, func(i, j int) bool {
+		a, b := concerts[i], concerts[j]
+		if a.ArtistID != b.ArtistID {
+			return a.ArtistID < b.ArtistID
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Date < b.Date
+	})
+}
+
+// Empty reports whether the two snapshots hold the same data
+func (r Report) Empty() bool {
+	return len(r.AddedArtists) == 0 && len(r.RemovedArtists) == 0 &&
+		len(r.AddedMembers) == 0 && len(r.RemovedMembers) == 0 &&
+		len(r.AddedConcerts) == 0 && len(r.RemovedConcerts) == 0
+}
+
+// Print writes a human readable report
+func (r Report) Print(w io.Writer) {
+	if r.Empty() {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+
+	for _, artist := range r.AddedArtists {
+		fmt.Fprintf(w, "+ artist  #%d %s\n", artist.ID, artist.Name)
+	}
+	for _, artist := range r.RemovedArtists {
+		fmt.Fprintf(w, "- artist  #%d %s\n", artist.ID, artist.Name)
+	}
+	for _, change := range r.AddedMembers {
+		fmt.Fprintf(w, "+ member  #%d %s: %s\n", change.ArtistID, r.names[change.ArtistID], change.Member)
+	}
+	for _, change := range r.RemovedMembers {
+		fmt.Fprintf(w, "- member  #%d %s: %s\n", change.ArtistID, r.names[change.ArtistID], change.Member)
+	}
+	for _, concert := range r.AddedConcerts {
+		fmt.Fprintf(w, "+ concert #%d %s: %s %s\n", concert.ArtistID, r.names[concert.ArtistID], concert.Location, concert.Date)
+	}
+	for _, concert := range r.RemovedConcerts {
+		fmt.Fprintf(w, "- concert #%d %s: %s %s\n", concert.ArtistID, r.names[concert.ArtistID], concert.Location, concert.Date)
+	}
+
+	fmt.Fprintf(w, "\n%d/%d artists, %d/%d members, %d/%d concerts (added/removed)\n",
+		len(r.AddedArtists), len(r.RemovedArtists),
+		len(r.AddedMembers), len(r.RemovedMembers),
+		len(r.AddedConcerts), len(r.RemovedConcerts))
+}
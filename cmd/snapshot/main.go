@@ -0,0 +1,85 @@
+// Command snapshot saves the Groupie Trackers API to a local directory and
+// compares saved snapshots.
+//
+//	go run ./cmd/snapshot fetch [-base URL] [-out data/snapshots]
+//	go run ./cmd/snapshot diff <old snapshot dir> <new snapshot dir>
+//
+// A snapshot directory can be served offline with GROUPIE_FIXTURES_DIR.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+const defaultOutDir = "data/snapshots"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "fetch":
+		err = runFetch(os.Args[2:])
+	case "diff":
+		err = runDiff(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snapshot:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  snapshot fetch [-base URL] [-out DIR]")
+	fmt.Fprintln(os.Stderr, "  snapshot diff OLD_DIR NEW_DIR")
+}
+
+// runFetch downloads every endpoint into a new versioned directory
+func runFetch(args []string) error {
+	flags := flag.NewFlagSet("fetch", flag.ExitOnError)
+	baseURL := flags.String("base", util.DefaultBaseURL, "base URL of the API")
+	outDir := flags.String("out", defaultOutDir, "directory receiving the snapshots")
+	flags.Parse(args)
+
+	dir, err := fetchSnapshot(util.NewHTTPSource(*baseURL), *outDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(dir)
+	return nil
+}
+
+// runDiff compares two snapshot directories
+func runDiff(args []string) error {
+	if len(args) != 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, dir := range args {
+		if err := verifySnapshot(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	report, err := diffSnapshots(util.NewFileSource(args[0]), util.NewFileSource(args[1]))
+	if err != nil {
+		return err
+	}
+
+	report.Print(os.Stdout)
+	return nil
+}
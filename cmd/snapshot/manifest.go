@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+const manifestFile = "manifest.json"
+
+// Manifest describes a snapshot directory
+type Manifest struct {
+	Version   string         `json:"version"`
+	Source    string         `json:"source"`
+	CreatedAt time.Time      `json:"created_at"`
+	Files     []ManifestFile `json:"files"`
+}
+
+// ManifestFile describes one saved endpoint
+type ManifestFile struct {
+	Endpoint  string    `json:"endpoint"`
+	Name      string    `json:"name"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+}
+
+// fetchSnapshot saves every endpoint of source into outDir/<version> and returns that directory
+func fetchSnapshot(source *util.HTTPSource, outDir string) (string, error) {
+	createdAt := time.Now().UTC()
+	version := createdAt.Format("20060102T150405Z")
+
+	// Write into a temporary directory so a failed fetch leaves no partial snapshot
+	tmpDir := filepath.Join(outDir, "."+version+".tmp")
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifest := Manifest{
+		Version:   version,
+		Source:    source.BaseURL,
+		CreatedAt: createdAt,
+	}
+
+	files := util.NewFileSource(tmpDir)
+	for _, endpoint := range util.Endpoints {
+		file, err := fetchEndpoint(source, endpoint, files.Path(endpoint))
+		if err != nil {
+			return "", err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	if err := writeManifest(tmpDir, manifest); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(outDir, version)
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// fetchEndpoint copies one endpoint payload to path, hashing it on the way
+func fetchEndpoint(source *util.HTTPSource, endpoint, path string) (ManifestFile, error) {
+	resp, err := source.Get(endpoint)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("%s: %w", endpoint, err)
+	}
+
+	// The payload must at least be valid JSON to be served later
+	if err := checkJSON(path); err != nil {
+		return ManifestFile{}, fmt.Errorf("%s: %w", endpoint, err)
+	}
+
+	return ManifestFile{
+		Endpoint:  endpoint,
+		Name:      filepath.Base(path),
+		FetchedAt: time.Now().UTC(),
+		Size:      size,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func checkJSON(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var payload json.RawMessage
+	return json.NewDecoder(file).Decode(&payload)
+}
+
+func writeManifest(dir string, manifest Manifest) error {
+	file, err := os.Create(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(manifest)
+}
+
+func readManifest(dir string) (Manifest, error) {
+	file, err := os.Open(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer file.Close()
+
+	var manifest Manifest
+	err = json.NewDecoder(file).Decode(&manifest)
+	return manifest, err
+}
+
+// verifySnapshot checks the files of a snapshot against its manifest checksums
+func verifySnapshot(dir string) error {
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return fmt.Errorf("%s: no readable manifest: %w", dir, err)
+	}
+
+	for _, entry := range manifest.Files {
+		sum, err := fileChecksum(filepath.Join(dir, entry.Name))
+		if err != nil {
+			return err
+		}
+		if sum != entry.SHA256 {
+			return fmt.Errorf("%s: checksum mismatch for %s", dir, entry.Name)
+		}
+	}
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -2,4 +2,7 @@ module github.com/YajiTV/groupie-tracker
 
 go 1.24.0
 
-require golang.org/x/crypto v0.46.0
+require (
+	golang.org/x/crypto v0.46.0
+	golang.org/x/text v0.32.0
+)
@@ -1,6 +1,117 @@
 package app
 
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/mail"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
 const (
 	Port      = ":8081"
 	StaticDir = "./static"
+
+	// CatalogRefreshInterval is how often the artist catalog is reloaded from the API
+	CatalogRefreshInterval = 15 * time.Minute
+
+	// Environment variables selecting the data source
+	APIBaseURLEnv  = "GROUPIE_API_URL"      // Base URL of the REST API (default: public API)
+	FixturesDirEnv = "GROUPIE_FIXTURES_DIR" // Directory with a saved JSON snapshot (works offline)
+
+	// Geocoding of concert locations
+	GazetteerFile  = "data/gazetteer.json"  // Bundled city, region and country coordinates
+	GeoCacheFile   = "data/geocache.json"   // Results of the remote provider
+	GeocoderEnv    = "GROUPIE_GEOCODER"     // "nominatim" enables remote lookups (default: offline only)
+	GeocoderURLEnv = "GROUPIE_GEOCODER_URL" // Base URL of the Nominatim server
+
+	// Login sessions
+	SessionsFile           = "data/sessions.json"
+	SessionStoreEnv        = "GROUPIE_SESSION_STORE" // "memory" keeps sessions in memory only (default: SessionsFile)
+	SessionJanitorInterval = 10 * time.Minute        // How often expired sessions are purged
+
+	// Outgoing email. Without an SMTP server, messages are written to MailDir.
+	SiteURLEnv      = "GROUPIE_SITE_URL"      // Public URL used in emailed links (default: http://localhost:8081)
+	SMTPAddrEnv     = "GROUPIE_SMTP_ADDR"     // host:port of the SMTP server
+	SMTPUsernameEnv = "GROUPIE_SMTP_USERNAME" // Optional SMTP credentials
+	SMTPPasswordEnv = "GROUPIE_SMTP_PASSWORD"
+	MailFromEnv     = "GROUPIE_MAIL_FROM" // Sender address (default: DefaultMailFrom)
+	DefaultMailFrom = "Groupie Tracker <no-reply@localhost>"
+	MailDir         = "data/mail"
+	SigningKeyFile  = "data/signing.key" // Signs the verification links
 )
+
+// newDataSource picks the artist data source from the environment
+func newDataSource() util.DataSource {
+	if dir := os.Getenv(FixturesDirEnv); dir != "" {
+		log.Printf("Données chargées depuis %s\n", dir)
+		return util.NewFileSource(dir)
+	}
+	return util.NewHTTPSource(os.Getenv(APIBaseURLEnv))
+}
+
+// newGeocoder loads the gazetteer and cache and picks the remote provider from the environment
+func newGeocoder() *geo.Geocoder {
+	gazetteer, err := geo.LoadGazetteer(GazetteerFile)
+	if err != nil {
+		log.Println("Gazetteer indisponible:", err)
+	}
+
+	cache, err := geo.OpenCache(GeoCacheFile)
+	if err != nil {
+		log.Println("Cache de géocodage illisible, ignoré:", err)
+	}
+
+	var provider geo.Provider
+	if os.Getenv(GeocoderEnv) == "nominatim" {
+		provider = geo.NewNominatimProvider(os.Getenv(GeocoderURLEnv))
+	}
+
+	return geo.NewGeocoder(gazetteer, cache, provider)
+}
+
+// newMailer picks SMTP when a server is configured, local files otherwise
+func newMailer() mail.Mailer {
+	from := os.Getenv(MailFromEnv)
+	if from == "" {
+		from = DefaultMailFrom
+	}
+
+	if addr := os.Getenv(SMTPAddrEnv); addr != "" {
+		return mail.SMTPMailer{
+			Addr:     addr,
+			From:     from,
+			Username: os.Getenv(SMTPUsernameEnv),
+			Password: os.Getenv(SMTPPasswordEnv),
+		}
+	}
+
+	log.Printf("Pas de serveur SMTP, emails écrits dans %s\n", MailDir)
+	return mail.FileMailer{Dir: MailDir, From: from}
+}
+
+// siteURL returns the public URL of the site, without trailing slash
+func siteURL() string {
+	if url := os.Getenv(SiteURLEnv); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost" + Port
+}
+
+// newSessionStore picks the session store from the environment
+func newSessionStore() auth.SessionStore {
+	if os.Getenv(SessionStoreEnv) == "memory" {
+		return auth.NewMemoryStore()
+	}
+
+	store, err := auth.OpenFileStore(SessionsFile)
+	if err != nil {
+		log.Println("Fichier de sessions illisible, sessions gardées en mémoire:", err)
+		return auth.NewMemoryStore()
+	}
+	return store
+}
@@ -3,12 +3,18 @@ package app
 import (
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
 	httphandlers "github.com/YajiTV/groupie-tracker/internal/http"
+	"github.com/YajiTV/groupie-tracker/internal/index"
+	"github.com/YajiTV/groupie-tracker/internal/mail"
 	"github.com/YajiTV/groupie-tracker/internal/storage"
+	"github.com/YajiTV/groupie-tracker/internal/util"
 )
 
-func SetupRouter() *http.ServeMux {
+func SetupRouter() http.Handler {
 	mux := http.NewServeMux()
 
 	fs := http.FileServer(http.Dir(StaticDir))
@@ -18,8 +24,16 @@ func SetupRouter() *http.ServeMux {
 	mux.HandleFunc("/", httphandlers.HomeHandler)
 	mux.HandleFunc("/artist/", httphandlers.ArtistHandler)
 	mux.HandleFunc("/search", httphandlers.SearchHandler)
+	mux.HandleFunc("/map", httphandlers.MapHandler)
+	mux.HandleFunc("/user/", httphandlers.PublicProfileHandler)
 
 	mux.HandleFunc("/api/suggestions", httphandlers.SuggestionsHandler)
+	mux.HandleFunc("/api/concerts.geojson", httphandlers.ConcertsGeoJSONHandler)
+	mux.HandleFunc("/api/artists", httphandlers.ArtistsAPIHandler)
+	mux.HandleFunc("/api/artists/", httphandlers.ArtistAPIHandler)
+	mux.HandleFunc("/api/map/concerts", httphandlers.MapConcertsHandler)
+	mux.HandleFunc("/api/nearby", httphandlers.NearbyHandler)
+	mux.HandleFunc("/api/facets", httphandlers.FacetsHandler)
 
 	// Authentication
 	mux.HandleFunc("/login", httphandlers.LoginPageHandler)
@@ -27,12 +41,27 @@ func SetupRouter() *http.ServeMux {
 	mux.HandleFunc("/logout", httphandlers.LogoutHandler)
 	mux.HandleFunc("/auth/login", httphandlers.LoginHandler)
 	mux.HandleFunc("/auth/register", httphandlers.RegisterHandler)
+	mux.HandleFunc("/login/2fa", httphandlers.SecondFactorPageHandler)
+	mux.HandleFunc("/auth/2fa", httphandlers.SecondFactorHandler)
+	mux.HandleFunc("/forgot", httphandlers.ForgotPasswordHandler)
+	mux.HandleFunc("/reset/", httphandlers.ResetPasswordHandler)
+	mux.HandleFunc("/verify", httphandlers.VerifyEmailHandler)
+	mux.HandleFunc("/verify/resend", httphandlers.ResendVerificationHandler)
 
 	// Protected pages
 	mux.HandleFunc("/profile", httphandlers.ProfileHandler)
 	mux.HandleFunc("/profile/update", httphandlers.UpdateProfileHandler)
+	mux.HandleFunc("/profile/password", httphandlers.ChangePasswordHandler)
+	mux.HandleFunc("/profile/2fa", httphandlers.TwoFactorPageHandler)
+	mux.HandleFunc("/profile/2fa/setup", httphandlers.StartTwoFactorHandler)
+	mux.HandleFunc("/profile/2fa/confirm", httphandlers.ConfirmTwoFactorHandler)
+	mux.HandleFunc("/profile/2fa/disable", httphandlers.DisableTwoFactorHandler)
+	mux.HandleFunc("/profile/sessions", httphandlers.SessionsHandler)
+	mux.HandleFunc("/profile/sessions/revoke", httphandlers.RevokeSessionHandler)
+	mux.HandleFunc("/profile/sessions/revoke-all", httphandlers.RevokeAllSessionsHandler)
 
-	return mux
+	// Every state-changing request must carry the CSRF token of its form
+	return auth.CSRF(mux, http.HandlerFunc(httphandlers.ForbiddenHandler))
 }
 
 func Start() {
@@ -41,6 +70,35 @@ func Start() {
 		log.Fatalf("Erreur initialisation stockage: %v", err)
 	}
 
+	// Keep logins across restarts and drop expired ones in the background
+	auth.Store = newSessionStore()
+	auth.StartJanitor(auth.Store, SessionJanitorInterval)
+
+	// Emails link back to the public address of the site, signed with a key kept across restarts
+	mail.Default = newMailer()
+	if err := auth.LoadSigningKey(SigningKeyFile); err != nil {
+		log.Println("Clé de signature illisible, les liens envoyés expireront au redémarrage:", err)
+	}
+	httphandlers.SiteURL = siteURL()
+
+	// Resolve concert coordinates in the background whenever the catalog changes
+	geo.Default = newGeocoder()
+	util.Catalog.OnLoad(func(snapshot *util.CatalogSnapshot) {
+		go geo.Default.Warm(snapshot.UniqueLocations())
+	})
+
+	// Rebuild the search index with each snapshot; requests keep the old one until it is swapped
+	util.Catalog.OnLoad(func(snapshot *util.CatalogSnapshot) {
+		start := time.Now()
+		ix := index.Build(snapshot)
+		index.Publish(ix)
+		log.Printf("Search index: %d terms in %v\n", ix.Len(), time.Since(start).Round(time.Microsecond))
+	})
+
+	// Load the artist catalog and keep it fresh in the background
+	util.Catalog.SetSource(newDataSource())
+	util.Catalog.Start(CatalogRefreshInterval)
+
 	log.Printf("Serveur sur http://localhost%s\n", Port)
 	log.Fatal(http.ListenAndServe(Port, SetupRouter()))
 }
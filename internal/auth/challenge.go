@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LoginChallenge is a login whose password was accepted, waiting for its second factor
+type LoginChallenge struct {
+	UserID    int
+	Client    Client
+	ExpiresAt time.Time
+}
+
+// ChallengeStore keeps pending logins in memory; a restart only means typing the password again
+type ChallengeStore struct {
+	challenges map[string]LoginChallenge // Keyed by the hash of the token, like sessions
+	mutex      sync.Mutex
+}
+
+// Challenges is the global store of pending logins
+var Challenges = &ChallengeStore{challenges: make(map[string]LoginChallenge)}
+
+const (
+	ChallengeCookieName = "login_challenge"
+	ChallengeDuration   = 5 * time.Minute // Time allowed to type the code
+)
+
+// Create starts a pending login and returns its token
+func (s *ChallengeStore) Create(userID int, client Client) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token := GenerateToken()
+	s.challenges[HashToken(token)] = LoginChallenge{
+		UserID:    userID,
+		Client:    client,
+		ExpiresAt: time.Now().Add(ChallengeDuration),
+	}
+	return token
+}
+
+// Get returns a pending login that has not expired
+func (s *ChallengeStore) Get(token string) (LoginChallenge, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := HashToken(token)
+	challenge, ok := s.challenges[key]
+	if !ok {
+		return LoginChallenge{}, false
+	}
+	if !time.Now().Before(challenge.ExpiresAt) {
+		delete(s.challenges, key)
+		return LoginChallenge{}, false
+	}
+	return challenge, true
+}
+
+// Delete ends a pending login
+func (s *ChallengeStore) Delete(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.challenges, HashToken(token))
+}
+
+// Purge deletes expired pending logins and returns how many were removed
+func (s *ChallengeStore) Purge() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for key, challenge := range s.challenges {
+		if !now.Before(challenge.ExpiresAt) {
+			delete(s.challenges, key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// SetChallengeCookie sets the pending login cookie
+func SetChallengeCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ChallengeCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(ChallengeDuration.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearChallengeCookie removes the pending login cookie
+func ClearChallengeCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ChallengeCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// ChallengeFromRequest returns the pending login of the request and its token
+func ChallengeFromRequest(r *http.Request) (string, LoginChallenge, bool) {
+	cookie, err := r.Cookie(ChallengeCookieName)
+	if err != nil {
+		return "", LoginChallenge{}, false
+	}
+	challenge, ok := Challenges.Get(cookie.Value)
+	return cookie.Value, challenge, ok
+}
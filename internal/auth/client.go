@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Client describes the browser a session was opened from
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// maxUserAgentLength bounds what is stored for each session
+const maxUserAgentLength = 256
+
+// ClientFromRequest returns the client of a request.
+// The IP is the peer address: forwarding headers can be forged, so they are ignored.
+func ClientFromRequest(r *http.Request) Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return Client{UserAgent: userAgent, IP: ip}
+}
+
+// browsers and systems are checked in order: Edge and Opera also announce Chrome,
+// Chrome also announces Safari, Android also announces Linux
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	systems = []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// Device summarizes the user agent of a session, e.g. "Firefox sur Linux"
+func (s SessionData) Device() string {
+	browser := firstToken(s.UserAgent, browsers)
+	system := firstToken(s.UserAgent, systems)
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " sur " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Appareil inconnu"
+	}
+}
+
+func firstToken(userAgent string, candidates []struct{ token, name string }) string {
+	for _, candidate := range candidates {
+		if strings.Contains(userAgent, candidate.token) {
+			return candidate.name
+		}
+	}
+	return ""
+}
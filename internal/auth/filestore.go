@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps sessions in a JSON file so they survive restarts.
+// The whole file is rewritten after each change.
+type FileStore struct {
+	filename string
+	sessions sessionMap
+	mutex    sync.Mutex
+}
+
+// OpenFileStore loads the sessions file, starting empty if it does not exist yet.
+// Sessions that expired while the server was down are dropped.
+func OpenFileStore(filename string) (*FileStore, error) {
+	s := &FileStore{
+		filename: filename,
+		sessions: make(sessionMap),
+	}
+
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&s.sessions); err != nil {
+		return nil, err
+	}
+	s.sessions.purge(time.Now())
+	return s, nil
+}
+
+// CreateSession creates a new session
+func (s *FileStore) CreateSession(userID int, username string, client Client) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessionID := s.sessions.create(userID, username, client, time.Now())
+	s.save()
+	return sessionID
+}
+
+// GetSession retrieves a session and extends its expiry
+func (s *FileStore) GetSession(sessionID string) (*SessionData, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, changed, ok := s.sessions.get(sessionID, time.Now())
+	if changed {
+		s.save()
+	}
+	return session, ok
+}
+
+// DeleteSession deletes a session
+func (s *FileStore) DeleteSession(sessionID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := sessionKey(sessionID)
+	if _, ok := s.sessions[key]; ok {
+		delete(s.sessions, key)
+		s.save()
+	}
+}
+
+// UserSessions lists the sessions of a user
+func (s *FileStore) UserSessions(userID int) []SessionData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sessions.user(userID, time.Now())
+}
+
+// RevokeSession deletes one session of a user
+func (s *FileStore) RevokeSession(userID int, key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.sessions.revoke(userID, key) {
+		return false
+	}
+	s.save()
+	return true
+}
+
+// RevokeUserSessions deletes every session of a user
+func (s *FileStore) RevokeUserSessions(userID int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.sessions.revokeUser(userID) > 0 {
+		s.save()
+	}
+}
+
+// PurgeExpired deletes expired sessions
+func (s *FileStore) PurgeExpired() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	purged := s.sessions.purge(time.Now())
+	if purged > 0 {
+		s.save()
+	}
+	return purged
+}
+
+// save rewrites the file through a temporary file so a crash never leaves it truncated.
+// Errors are logged: the sessions stay valid in memory.
+func (s *FileStore) save() {
+	if err := s.write(); err != nil {
+		log.Println("Sauvegarde des sessions impossible:", err)
+	}
+}
+
+func (s *FileStore) write() error {
+	dir := filepath.Dir(s.filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(s.filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // No-op once renamed
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s.sessions); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.filename)
+}
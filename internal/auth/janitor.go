@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"log"
+	"time"
+)
+
+// StartJanitor purges the expired sessions of store, the stale login
+// throttling entries and the abandoned second login steps every interval.
+// The returned function stops it.
+func StartJanitor(store SessionStore, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if purged := store.PurgeExpired(); purged > 0 {
+					log.Printf("Sessions expirées supprimées: %d\n", purged)
+				}
+				LoginUserLimiter.Purge()
+				LoginIPLimiter.Purge()
+				MailLimiter.Purge()
+				Challenges.Purge()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in memory; they are lost on restart
+type MemoryStore struct {
+	sessions sessionMap
+	mutex    sync.Mutex
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(sessionMap)}
+}
+
+// CreateSession creates a new session
+func (s *MemoryStore) CreateSession(userID int, username string, client Client) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sessions.create(userID, username, client, time.Now())
+}
+
+// GetSession retrieves a session and extends its expiry
+func (s *MemoryStore) GetSession(sessionID string) (*SessionData, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, _, ok := s.sessions.get(sessionID, time.Now())
+	return session, ok
+}
+
+// DeleteSession deletes a session
+func (s *MemoryStore) DeleteSession(sessionID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, sessionKey(sessionID))
+}
+
+// UserSessions lists the sessions of a user
+func (s *MemoryStore) UserSessions(userID int) []SessionData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sessions.user(userID, time.Now())
+}
+
+// RevokeSession deletes one session of a user
+func (s *MemoryStore) RevokeSession(userID int, key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sessions.revoke(userID, key)
+}
+
+// RevokeUserSessions deletes every session of a user
+func (s *MemoryStore) RevokeUserSessions(userID int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sessions.revokeUser(userID)
+}
+
+// PurgeExpired deletes expired sessions
+func (s *MemoryStore) PurgeExpired() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sessions.purge(time.Now())
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter slows down repeated login failures for a key (an IP or a username).
+// The first FreeAttempts failures cost nothing, each further one doubles the delay
+// before the next attempt, and MaxFailures failures lock the key for Lockout.
+// Failures are forgotten after Window without a new one.
+type Limiter struct {
+	FreeAttempts int
+	MaxFailures  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Lockout      time.Duration
+	Window       time.Duration
+	Now          func() time.Time // Replaced by a fake clock in tests
+
+	entries map[string]*limiterEntry
+	mutex   sync.Mutex
+}
+
+type limiterEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	locked       bool // blockedUntil is a lockout, not a backoff delay
+}
+
+// Login throttling. IPs get more room than usernames since users behind
+// the same NAT share one address.
+var (
+	LoginUserLimiter = &Limiter{
+		FreeAttempts: 3,
+		MaxFailures:  10,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+		Now:          time.Now,
+	}
+	LoginIPLimiter = &Limiter{
+		FreeAttempts: 10,
+		MaxFailures:  50,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+		Now:          time.Now,
+	}
+
+	// MailLimiter counts the emails requested for an address or an account, so the
+	// reset and verification forms cannot flood a mailbox
+	MailLimiter = &Limiter{
+		FreeAttempts: 3,
+		MaxFailures:  10,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Lockout:      24 * time.Hour,
+		Window:       24 * time.Hour,
+		Now:          time.Now,
+	}
+)
+
+// UsernameKey normalizes a username so case variants share one counter.
+// Registration rejects names that differ only by case, so a key is one account.
+func UsernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check returns how long key must wait before its next attempt, and whether it is locked
+func (l *Limiter) Check(key string) (retryAfter time.Duration, locked bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry := l.entry(key, l.Now())
+	if entry == nil {
+		return 0, false
+	}
+	return l.wait(entry, l.Now())
+}
+
+// Failure records a failed attempt and returns the resulting wait, like Check
+func (l *Limiter) Failure(key string) (retryAfter time.Duration, locked bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.Now()
+	entry := l.entry(key, now)
+	if entry == nil {
+		if l.entries == nil {
+			l.entries = make(map[string]*limiterEntry)
+		}
+		entry = &limiterEntry{}
+		l.entries[key] = entry
+	}
+
+	entry.failures++
+	entry.lastFailure = now
+	switch {
+	case entry.failures >= l.MaxFailures:
+		entry.blockedUntil = now.Add(l.Lockout)
+		entry.locked = true
+	case entry.failures > l.FreeAttempts:
+		entry.blockedUntil = now.Add(l.delay(entry.failures - l.FreeAttempts))
+		entry.locked = false
+	}
+	return l.wait(entry, now)
+}
+
+// Reset forgets the failures of key, after a successful login
+func (l *Limiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.entries, key)
+}
+
+// Purge forgets the keys whose failures are outside the window and returns how many were removed
+func (l *Limiter) Purge() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.Now()
+	purged := 0
+	for key, entry := range l.entries {
+		if l.stale(entry, now) {
+			delete(l.entries, key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// entry returns the live entry of key, dropping it once stale
+func (l *Limiter) entry(key string, now time.Time) *limiterEntry {
+	entry, ok := l.entries[key]
+	if !ok {
+		return nil
+	}
+	if l.stale(entry, now) {
+		delete(l.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (l *Limiter) stale(entry *limiterEntry, now time.Time) bool {
+	return !now.Before(entry.blockedUntil) && now.Sub(entry.lastFailure) >= l.Window
+}
+
+func (l *Limiter) wait(entry *limiterEntry, now time.Time) (time.Duration, bool) {
+	if !now.Before(entry.blockedUntil) {
+		return 0, false
+	}
+	return entry.blockedUntil.Sub(now), entry.locked
+}
+
+// delay is the backoff after the nth paying failure: BaseDelay, then doubled, up to MaxDelay
+func (l *Limiter) delay(n int) time.Duration {
+	delay := l.BaseDelay
+	for i := 1; i < n && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		delay = l.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a Limiter.Now that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(clock *fakeClock) *Limiter {
+	return &Limiter{
+		FreeAttempts: 3,
+		MaxFailures:  10,
+		BaseDelay:    time.Second,
+		MaxDelay:     8 * time.Second,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+		Now:          clock.Now,
+	}
+}
+
+func TestLimiterFailureBackoff(t *testing.T) {
+	tests := []struct {
+		failure    int
+		wantWait   time.Duration
+		wantLocked bool
+	}{
+		{1, 0, false},
+		{2, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{7, 8 * time.Second, false},
+		{8, 8 * time.Second, false}, // Capped at MaxDelay
+		{9, 8 * time.Second, false},
+		{10, 15 * time.Minute, true},
+		{11, 15 * time.Minute, true},
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := newTestLimiter(clock)
+	for _, tt := range tests {
+		wait, locked := limiter.Failure("alice")
+		if wait != tt.wantWait || locked != tt.wantLocked {
+			t.Errorf("failure %d: got (%v, %v), want (%v, %v)", tt.failure, wait, locked, tt.wantWait, tt.wantLocked)
+		}
+	}
+}
+
+func TestLimiterScenarios(t *testing.T) {
+	type step struct {
+		advance    time.Duration
+		action     string // "fail", "check", "reset", "purge", or "record" for a failure whose wait is not checked
+		key        string
+		wantWait   time.Duration
+		wantLocked bool
+		wantPurged int
+	}
+	failures := func(n int, key string) []step {
+		steps := make([]step, n)
+		for i := range steps {
+			steps[i] = step{action: "record", key: key}
+		}
+		return steps
+	}
+	concat := func(parts ...[]step) []step {
+		var steps []step
+		for _, part := range parts {
+			steps = append(steps, part...)
+		}
+		return steps
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "free attempts do not wait",
+			steps: concat(failures(3, "alice"), []step{{action: "check", key: "alice"}}),
+		},
+		{
+			name: "backoff runs out with time",
+			steps: concat(failures(4, "alice"), []step{
+				{action: "check", key: "alice", wantWait: time.Second},
+				{advance: 400 * time.Millisecond, action: "check", key: "alice", wantWait: 600 * time.Millisecond},
+				{advance: 600 * time.Millisecond, action: "check", key: "alice"},
+			}),
+		},
+		{
+			name: "lockout lasts Lockout",
+			steps: concat(failures(10, "alice"), []step{
+				{action: "check", key: "alice", wantWait: 15 * time.Minute, wantLocked: true},
+				{advance: 14 * time.Minute, action: "check", key: "alice", wantWait: time.Minute, wantLocked: true},
+				{advance: time.Minute, action: "check", key: "alice"},
+			}),
+		},
+		{
+			name: "failures are remembered within the window",
+			steps: concat(failures(3, "alice"), []step{
+				{advance: 59 * time.Minute, action: "fail", key: "alice", wantWait: time.Second},
+			}),
+		},
+		{
+			name: "failures are forgotten after the window",
+			steps: concat(failures(9, "alice"), []step{
+				{advance: time.Hour, action: "check", key: "alice"},
+				{action: "fail", key: "alice"},
+			}),
+		},
+		{
+			name: "keys are counted apart",
+			steps: concat(failures(4, "alice"), []step{
+				{action: "check", key: "bob"},
+				{action: "fail", key: "bob"},
+			}),
+		},
+		{
+			name: "reset after a success",
+			steps: concat(failures(10, "alice"), []step{
+				{action: "reset", key: "alice"},
+				{action: "check", key: "alice"},
+				{action: "fail", key: "alice"},
+			}),
+		},
+		{
+			name: "purge drops keys outside the window",
+			steps: concat(failures(2, "alice"), []step{
+				{advance: 30 * time.Minute, action: "fail", key: "bob"},
+				{advance: 30 * time.Minute, action: "purge", wantPurged: 1},
+				{action: "purge"},
+				{advance: 30 * time.Minute, action: "purge", wantPurged: 1},
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+			limiter := newTestLimiter(clock)
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+
+				var wait time.Duration
+				var locked bool
+				switch s.action {
+				case "record":
+					limiter.Failure(s.key)
+					continue
+				case "fail":
+					wait, locked = limiter.Failure(s.key)
+				case "check":
+					wait, locked = limiter.Check(s.key)
+				case "reset":
+					limiter.Reset(s.key)
+					continue
+				case "purge":
+					if purged := limiter.Purge(); purged != s.wantPurged {
+						t.Errorf("step %d: Purge() = %d, want %d", i, purged, s.wantPurged)
+					}
+					continue
+				}
+
+				if wait != s.wantWait || locked != s.wantLocked {
+					t.Errorf("step %d (%s %s): got (%v, %v), want (%v, %v)",
+						i, s.action, s.key, wait, locked, s.wantWait, s.wantLocked)
+				}
+			}
+		})
+	}
+}
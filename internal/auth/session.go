@@ -2,35 +2,55 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
-	"sync"
+	"sort"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// SessionStore stores sessions in memory
-type SessionStore struct {
-	sessions map[string]*SessionData
-	mutex    sync.RWMutex
+// SessionStore keeps the sessions of logged-in users.
+// Sessions expire after SessionDuration without activity and SessionMaxAge after login.
+type SessionStore interface {
+	// CreateSession creates a session and returns its ID
+	CreateSession(userID int, username string, client Client) string
+	// GetSession returns a copy of a valid session, recording the activity
+	// and sliding its expiry forward
+	GetSession(sessionID string) (*SessionData, bool)
+	// DeleteSession deletes a session
+	DeleteSession(sessionID string)
+	// UserSessions returns copies of the valid sessions of a user, most recently seen first
+	UserSessions(userID int) []SessionData
+	// RevokeSession deletes the session of a user with the given key and reports whether it existed
+	RevokeSession(userID int, key string) bool
+	// RevokeUserSessions deletes every session of a user
+	RevokeUserSessions(userID int)
+	// PurgeExpired deletes expired sessions and returns how many were removed
+	PurgeExpired() int
 }
 
 // SessionData contains session data
 type SessionData struct {
-	UserID    int
-	Username  string
-	ExpiresAt time.Time
+	Key       string    `json:"-"` // Public handle of the session, never the ID itself
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// Store is the global session store
-var Store = &SessionStore{
-	sessions: make(map[string]*SessionData),
-}
+// Store is the global session store, replaced at startup by the configured one
+var Store SessionStore = NewMemoryStore()
 
 const (
-	SessionCookieName = "session_id"
-	SessionDuration   = 24 * time.Hour // Session validity duration
+	SessionCookieName    = "session_id"
+	SessionDuration      = 24 * time.Hour      // Session validity without activity
+	SessionMaxAge        = 30 * 24 * time.Hour // Absolute session lifetime, also the cookie lifetime
+	SessionRenewInterval = time.Minute         // Minimum delay between two activity updates
 )
 
 // GenerateSessionID generates a unique 32-character hexadecimal session ID
@@ -40,44 +60,127 @@ func GenerateSessionID() string {
 	return hex.EncodeToString(b)
 }
 
-// CreateSession creates a new session
-func (s *SessionStore) CreateSession(userID int, username string) string {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// GenerateToken generates a 64-character hexadecimal secret for emailed links
+func GenerateToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// HashToken returns the SHA-256 of a secret token, what gets stored instead of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionKey is the key a session is stored under.
+// Only a hash of the ID is kept, so a leaked sessions file cannot be replayed.
+func sessionKey(sessionID string) string {
+	return HashToken(sessionID)
+}
+
+// sessionMap holds sessions by key. Callers hold the lock of their store.
+type sessionMap map[string]*SessionData
 
+// create adds a session for a user and returns its ID
+func (m sessionMap) create(userID int, username string, client Client, now time.Time) string {
 	sessionID := GenerateSessionID()
-	s.sessions[sessionID] = &SessionData{
+	m[sessionKey(sessionID)] = &SessionData{
 		UserID:    userID,
 		Username:  username,
-		ExpiresAt: time.Now().Add(SessionDuration),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(SessionDuration),
 	}
 	return sessionID
 }
 
-// GetSession retrieves a session
-func (s *SessionStore) GetSession(sessionID string) (*SessionData, bool) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	session, exists := s.sessions[sessionID]
+// get returns a copy of a valid session, deleting it when expired.
+// changed reports whether the map was modified.
+func (m sessionMap) get(sessionID string, now time.Time) (session *SessionData, changed, ok bool) {
+	key := sessionKey(sessionID)
+	stored, exists := m[key]
 	if !exists {
-		return nil, false
+		return nil, false, false
 	}
 
-	// Check expiration
-	if time.Now().After(session.ExpiresAt) {
-		delete(s.sessions, sessionID)
-		return nil, false
+	if stored.expired(now) {
+		delete(m, key)
+		return nil, true, false
+	}
+
+	// Sliding expiration, capped by the absolute lifetime.
+	// Throttled so the file store is not rewritten on every request.
+	if now.Sub(stored.LastSeen) >= SessionRenewInterval {
+		stored.LastSeen = now
+		stored.ExpiresAt = now.Add(SessionDuration)
+		if limit := stored.CreatedAt.Add(SessionMaxAge); stored.ExpiresAt.After(limit) {
+			stored.ExpiresAt = limit
+		}
+		changed = true
 	}
+	return stored.copy(key), changed, true
+}
+
+// user returns copies of the valid sessions of a user, most recently seen first
+func (m sessionMap) user(userID int, now time.Time) []SessionData {
+	sessions := []SessionData{}
+	for key, session := range m {
+		if session.UserID == userID && !session.expired(now) {
+			sessions = append(sessions, *session.copy(key))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions
+}
+
+// revoke deletes the session of a user stored under key
+func (m sessionMap) revoke(userID int, key string) bool {
+	session, ok := m[key]
+	if !ok || session.UserID != userID {
+		return false
+	}
+	delete(m, key)
+	return true
+}
+
+// revokeUser deletes every session of a user and returns how many were removed
+func (m sessionMap) revokeUser(userID int) int {
+	revoked := 0
+	for key, session := range m {
+		if session.UserID == userID {
+			delete(m, key)
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// purge deletes expired sessions and returns how many were removed
+func (m sessionMap) purge(now time.Time) int {
+	purged := 0
+	for key, session := range m {
+		if session.expired(now) {
+			delete(m, key)
+			purged++
+		}
+	}
+	return purged
+}
 
-	return session, true
+func (s *SessionData) expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
 }
 
-// DeleteSession deletes a session
-func (s *SessionStore) DeleteSession(sessionID string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	delete(s.sessions, sessionID)
+// copy detaches a session from the store so callers never share it with other requests
+func (s *SessionData) copy(key string) *SessionData {
+	c := *s
+	c.Key = key
+	return &c
 }
 
 // SetCookie sets the session cookie
@@ -86,7 +189,7 @@ func SetCookie(w http.ResponseWriter, sessionID string) {
 		Name:     SessionCookieName,
 		Value:    sessionID,
 		Path:     "/",
-		MaxAge:   int(SessionDuration.Seconds()),
+		MaxAge:   int(SessionMaxAge.Seconds()), // The store enforces the shorter idle timeout
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 	})
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signingKey signs the links sent by email. Unlike the CSRF key it must survive
+// restarts, so LoadSigningKey replaces this temporary one with a key kept on disk.
+var signingKey = randomKey()
+
+// ErrInvalidToken is returned for forged, malformed or expired signed tokens
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// LoadSigningKey reads the signing key from filename, creating it on first start
+func LoadSigningKey(filename string) error {
+	key, err := os.ReadFile(filename)
+	if err == nil && len(key) >= 32 {
+		signingKey = key
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	key = randomKey()
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, key, 0600); err != nil {
+		return err
+	}
+	signingKey = key
+	return nil
+}
+
+// SignToken returns a URL-safe token carrying payload until expires
+func SignToken(payload string, expires time.Time) string {
+	body := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(expires.Unix(), 10) + "." + payload))
+	return body + "." + signature(body)
+}
+
+// VerifyToken returns the payload of a token signed by SignToken that has not expired
+func VerifyToken(token string) (string, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(signature(body))) {
+		return "", ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	expires, payload, ok := strings.Cut(string(decoded), ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(unix, 0)) {
+		return "", ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func signature(body string) string {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports
+const (
+	TOTPIssuer = "Groupie Tracker"
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	TOTPSkew   = 1 // Accepted steps before and after the current one, for clock drift
+
+	RecoveryCodeCount  = 10
+	recoveryCodeLength = 8 // Base32 characters, without the dash
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random 160-bit secret in base32, as shown to authenticator apps
+func GenerateTOTPSecret() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return base32NoPadding.EncodeToString(b)
+}
+
+// TOTPURI returns the otpauth:// URI encoded in the enrollment QR code.
+// Spaces are escaped as %20: some apps show a literal + otherwise.
+func TOTPURI(account, secret string) string {
+	issuer := url.PathEscape(TOTPIssuer)
+	return "otpauth://totp/" + issuer + ":" + url.PathEscape(account) +
+		"?secret=" + secret +
+		"&issuer=" + issuer +
+		"&algorithm=SHA1&digits=" + strconv.Itoa(TOTPDigits) +
+		"&period=" + strconv.Itoa(int(TOTPPeriod.Seconds()))
+}
+
+// TOTPCode returns the code of secret for the time step containing t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpCounter(t)), nil
+}
+
+// VerifyTOTP checks a code against the steps around t. A step is only accepted
+// after lastCounter, so a code cannot be replayed. It returns the accepted step.
+func VerifyTOTP(secret, code string, t time.Time, lastCounter int64) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	code = normalizeCode(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := totpCounter(t)
+	for counter := current - TOTPSkew; counter <= current+TOTPSkew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+		if hmac.Equal([]byte(hotp(key, counter)), []byte(code)) {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// hotp computes an HOTP value (RFC 4226) with dynamic truncation
+func hotp(key []byte, counter int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		modulus *= 10
+	}
+	code := strconv.FormatUint(uint64(value%modulus), 10)
+	return strings.Repeat("0", TOTPDigits-len(code)) + code
+}
+
+// GenerateRecoveryCodes returns one-time codes to show once, and the hashes to store.
+// 40 bits are too few for a fast hash, so they are hashed with bcrypt like passwords.
+func GenerateRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		rand.Read(b)
+		code := strings.ToLower(base32NoPadding.EncodeToString(b)) // 8 characters, 40 bits
+		hash, err := HashPassword(code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+// UseRecoveryCode looks code up in hashes and returns the hashes left without it
+func UseRecoveryCode(hashes []string, code string) ([]string, bool) {
+	code = normalizeCode(code)
+	if len(code) != recoveryCodeLength { // Spares the bcrypt comparisons for app codes
+		return hashes, false
+	}
+	for i, hash := range hashes {
+		if CheckPassword(hash, code) {
+			left := append([]string{}, hashes[:i]...)
+			return append(left, hashes[i+1:]...), true
+		}
+	}
+	return hashes, false
+}
+
+// normalizeCode drops the spaces and dashes users type in codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
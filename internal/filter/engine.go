@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/index"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Engine applies filters to a catalog snapshot
+type Engine struct {
+	catalog  *util.CatalogSnapshot
+	geocoder *geo.Geocoder
+}
+
+// Match is an artist kept by a filter, with its concerts satisfying the concert criteria
+type Match struct {
+	Artist   util.Artist
+	Concerts []util.Concert // Sorted by date
+}
+
+// NewEngine creates an engine over a snapshot
+func NewEngine(catalog *util.CatalogSnapshot, geocoder *geo.Geocoder) *Engine {
+	return &Engine{catalog: catalog, geocoder: geocoder}
+}
+
+// Match returns the matching artists in catalog order.
+// extra adds concert criteria that are not part of the filter, such as a date window.
+func (e *Engine) Match(f Filter, extra ...ConcertPredicate) []Match {
+	return e.match(e.catalog.Artists, f, extra)
+}
+
+// MatchArtist applies a filter to a single artist
+func (e *Engine) MatchArtist(artist util.Artist, f Filter, extra ...ConcertPredicate) (Match, bool) {
+	matches := e.match([]util.Artist{artist}, f, extra)
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	return matches[0], true
+}
+
+// Artists returns the matching artists in catalog order
+func (e *Engine) Artists(f Filter) []util.Artist {
+	var artists []util.Artist
+	for _, match := range e.Match(f) {
+		artists = append(artists, match.Artist)
+	}
+	return artists
+}
+
+func (e *Engine) match(artists []util.Artist, f Filter, extra []ConcertPredicate) []Match {
+	keepArtist := AllArtists(f.ArtistPredicates()...)
+	concertPredicates := append(f.ConcertPredicates(e.geocoder), extra...)
+	keepConcert := AllConcerts(concertPredicates...)
+
+	candidates := e.candidates(f)
+
+	var matches []Match
+	for _, artist := range artists {
+		if candidates != nil && !candidates.Has(artist.ID) {
+			continue
+		}
+		if !keepArtist(artist) {
+			continue
+		}
+
+		concerts := e.catalog.ArtistConcerts(artist.ID)
+		if len(concertPredicates) > 0 {
+			var kept []util.Concert
+			for _, concert := range concerts {
+				if keepConcert(concert) {
+					kept = append(kept, concert)
+				}
+			}
+			if len(kept) == 0 {
+				continue
+			}
+			concerts = kept
+		}
+
+		matches = append(matches, Match{Artist: artist, Concerts: concerts})
+	}
+	return matches
+}
+
+// candidates narrows the artists worth checking with the catalog index.
+// It returns nil, meaning every artist, when the index is not ready or no criterion uses it.
+func (e *Engine) candidates(f Filter) index.Set {
+	ix := index.For(e.catalog)
+	if ix == nil {
+		return nil
+	}
+
+	var candidates index.Set
+	if f.HasPlaces() {
+		places := make(index.Set)
+		for _, location := range f.Locations {
+			addAll(places, ix.Lookup(location, index.FieldSlug|index.FieldCity|index.FieldCountry))
+		}
+		for _, country := range f.Countries {
+			addAll(places, ix.Lookup(country, index.FieldCountry))
+		}
+		for _, continent := range f.Continents {
+			addAll(places, ix.Lookup(continent, index.FieldContinent))
+		}
+		candidates = places
+	}
+	if f.Query != "" {
+		candidates = index.Intersect(candidates, ix.Search(f.Query, index.FieldText))
+	}
+	return candidates
+}
+
+func addAll(dst, src index.Set) {
+	for id := range src {
+		dst[id] = true
+	}
+}
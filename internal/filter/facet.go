@@ -0,0 +1,218 @@
+package filter
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// FacetValue is one option of a facet with the number of artists it would leave
+type FacetValue struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+	Min      int    `json:"min,omitempty"` // Year range of a decade
+	Max      int    `json:"max,omitempty"`
+}
+
+// Facets counts the artists behind each filter option.
+// Each facet is computed with every other active criterion but its own,
+// so ticking a second member count never shows zero for the first one.
+type Facets struct {
+	MemberCounts    []FacetValue `json:"member_counts"`
+	CreationDecades []FacetValue `json:"creation_decades"`
+	AlbumDecades    []FacetValue `json:"album_decades"`
+	Locations       []FacetValue `json:"locations"` // Value is the location slug
+	Countries       []FacetValue `json:"countries"`
+	Continents      []FacetValue `json:"continents"` // In util.Continents order
+}
+
+// Facets computes the facet counts of a filter
+func (e *Engine) Facets(f Filter) Facets {
+	facets := Facets{
+		MemberCounts: []FacetValue{},
+		Locations:    []FacetValue{},
+		Countries:    []FacetValue{},
+		Continents:   []FacetValue{},
+	}
+
+	// Every option of the catalog is listed, with a zero count when the other criteria exclude it
+	memberCounts := make(map[int]int)
+	creationDecades := make(map[int]int)
+	albumDecades := make(map[int]int)
+	locationCounts := make(map[string]int)
+	locationLabels := make(map[string]string)
+	countryCounts := make(map[string]int)
+	continentCounts := make(map[string]int)
+	for _, artist := range e.catalog.Artists {
+		memberCounts[len(artist.Members)] = 0
+		creationDecades[decade(artist.CreationDate)] = 0
+		if year, ok := AlbumYear(artist); ok {
+			albumDecades[decade(year)] = 0
+		}
+	}
+	for _, location := range e.catalog.UniqueLocations() {
+		locationCounts[location.Slug] = 0
+		locationLabels[location.Slug] = location.String()
+		countryCounts[location.Country] = 0
+		if location.Continent != "" {
+			continentCounts[location.Continent] = 0
+		}
+	}
+
+	// Member counts
+	without := f
+	without.MemberCounts = nil
+	for _, match := range e.Match(without) {
+		memberCounts[len(match.Artist.Members)]++
+	}
+	for count, artists := range memberCounts {
+		facets.MemberCounts = append(facets.MemberCounts, FacetValue{
+			Value:    strconv.Itoa(count),
+			Label:    strconv.Itoa(count),
+			Count:    artists,
+			Selected: f.HasMemberCount(count),
+		})
+	}
+	sortNumericFacet(facets.MemberCounts)
+
+	// Creation decades
+	without = f
+	without.CreationYearMin, without.CreationYearMax = 0, 0
+	for _, match := range e.Match(without) {
+		creationDecades[decade(match.Artist.CreationDate)]++
+	}
+	facets.CreationDecades = decadeFacet(creationDecades, f.CreationYearMin, f.CreationYearMax)
+
+	// Album decades
+	without = f
+	without.AlbumYearMin, without.AlbumYearMax = 0, 0
+	for _, match := range e.Match(without) {
+		if year, ok := AlbumYear(match.Artist); ok {
+			albumDecades[decade(year)]++
+		}
+	}
+	facets.AlbumDecades = decadeFacet(albumDecades, f.AlbumYearMin, f.AlbumYearMax)
+
+	// Places, each artist counted once per place. Continents, countries and cities
+	// are one hierarchical criterion, so they are left out together.
+	without = f
+	without.Locations, without.Countries, without.Continents = nil, nil, nil
+	for _, match := range e.Match(without) {
+		seenLocations := make(map[string]bool)
+		seenCountries := make(map[string]bool)
+		seenContinents := make(map[string]bool)
+		for _, concert := range match.Concerts {
+			location := concert.Location
+			if !seenLocations[location.Slug] {
+				seenLocations[location.Slug] = true
+				locationCounts[location.Slug]++
+				locationLabels[location.Slug] = location.String()
+			}
+			if !seenCountries[location.Country] {
+				seenCountries[location.Country] = true
+				countryCounts[location.Country]++
+			}
+			if location.Continent != "" && !seenContinents[location.Continent] {
+				seenContinents[location.Continent] = true
+				continentCounts[location.Continent]++
+			}
+		}
+	}
+	for slug, artists := range locationCounts {
+		facets.Locations = append(facets.Locations, FacetValue{
+			Value:    slug,
+			Label:    locationLabels[slug],
+			Count:    artists,
+			Selected: f.HasLocation(slug),
+		})
+	}
+	for country, artists := range countryCounts {
+		facets.Countries = append(facets.Countries, FacetValue{
+			Value:    country,
+			Label:    country,
+			Count:    artists,
+			Selected: f.HasCountry(country),
+		})
+	}
+	for _, continent := range util.Continents {
+		if artists, ok := continentCounts[continent]; ok {
+			facets.Continents = append(facets.Continents, FacetValue{
+				Value:    continent,
+				Label:    continent,
+				Count:    artists,
+				Selected: f.HasContinent(continent),
+			})
+		}
+	}
+	sortFacetByLabel(facets.Locations)
+	sortFacetByLabel(facets.Countries)
+
+	return facets
+}
+
+// MemberCount returns the number of artists with count members
+func (f Facets) MemberCount(count int) int {
+	return facetCount(f.MemberCounts, strconv.Itoa(count))
+}
+
+// LocationCount returns the number of artists playing at a location slug
+func (f Facets) LocationCount(slug string) int {
+	return facetCount(f.Locations, slug)
+}
+
+// CountryCount returns the number of artists playing in a country
+func (f Facets) CountryCount(country string) int {
+	return facetCount(f.Countries, country)
+}
+
+// ContinentCount returns the number of artists playing on a continent
+func (f Facets) ContinentCount(continent string) int {
+	return facetCount(f.Continents, continent)
+}
+
+func facetCount(values []FacetValue, value string) int {
+	for _, v := range values {
+		if v.Value == value {
+			return v.Count
+		}
+	}
+	return 0
+}
+
+// decadeFacet turns decade counts into facet values, selected when inside the active range
+func decadeFacet(counts map[int]int, min, max int) []FacetValue {
+	values := make([]FacetValue, 0, len(counts))
+	for start, artists := range counts {
+		values = append(values, FacetValue{
+			Value:    strconv.Itoa(start),
+			Label:    "Années " + strconv.Itoa(start),
+			Count:    artists,
+			Selected: (min > 0 || max > 0) && inRange(start, min, max) && inRange(start+9, min, max),
+			Min:      start,
+			Max:      start + 9,
+		})
+	}
+	sortNumericFacet(values)
+	return values
+}
+
+func decade(year int) int {
+	return year / 10 * 10
+}
+
+func sortNumericFacet(values []FacetValue) {
+	sort.Slice(values, func(i, j int) bool {
+		a, _ := strconv.Atoi(values[i].Value)
+		b, _ := strconv.Atoi(values[j].Value)
+		return a < b
+	})
+}
+
+func sortFacetByLabel(values []FacetValue) {
+	sort.Slice(values, func(i, j int) bool {
+		return util.FoldText(values[i].Label) < util.FoldText(values[j].Label)
+	})
+}
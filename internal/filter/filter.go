@@ -0,0 +1,243 @@
+// Package filter narrows the artist catalog from query-string criteria.
+// Home, search, the map and the JSON API share it so they always agree.
+package filter
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+)
+
+// Radius of the "near me" search
+const (
+	DefaultRadiusKm = 50.0
+	MinRadiusKm     = 1.0
+	MaxRadiusKm     = 20000.0 // Half the Earth's circumference
+)
+
+// Query-string parameter names
+const (
+	ParamCreationYearMin = "creation_year_min"
+	ParamCreationYearMax = "creation_year_max"
+	ParamAlbumYearMin    = "album_year_min"
+	ParamAlbumYearMax    = "album_year_max"
+	ParamMemberCount     = "member_count"
+	ParamLocation        = "location"
+	ParamCountry         = "country"
+	ParamContinent       = "continent"
+	ParamQuery           = "q"
+	ParamNear            = "near"
+	ParamNearLat         = "near_lat"
+	ParamNearLon         = "near_lon"
+	ParamRadiusKm        = "radius_km"
+	ParamConcertFrom     = "concert_from"
+	ParamConcertTo       = "concert_to"
+)
+
+// DateLayout is the format of concert_from and concert_to ("2019-06-21")
+const DateLayout = "2006-01-02"
+
+// Filter is a set of criteria. The zero value keeps everything.
+type Filter struct {
+	CreationYearMin int
+	CreationYearMax int
+	AlbumYearMin    int
+	AlbumYearMax    int
+	MemberCounts    []int    // Sorted, without duplicates
+	Locations       []string // Slugs or place names
+	Countries       []string // Country display names
+	Continents      []string // Continent display names, see util.Continents
+	Query           string
+
+	// "Concerts near me": a place name or coordinates, plus a radius
+	Near     string
+	NearLat  string
+	NearLon  string
+	RadiusKm float64
+	Origin   *geo.Coord // Resolved center, nil when the search is off
+
+	// Concert date window, inclusive; zero bounds are open
+	ConcertFrom time.Time
+	ConcertTo   time.Time
+
+	// Where is an extra criterion built in code, such as a parsed search query. It is not encoded.
+	Where ArtistPredicate
+}
+
+// HasConcertCriteria reports whether the filter narrows the concerts, not only the artists
+func (f Filter) HasConcertCriteria() bool {
+	return f.HasPlaces() || f.Origin != nil || !f.ConcertFrom.IsZero() || !f.ConcertTo.IsZero()
+}
+
+// HasPlaces reports whether a location, country or continent is selected
+func (f Filter) HasPlaces() bool {
+	return len(f.Locations) > 0 || len(f.Countries) > 0 || len(f.Continents) > 0
+}
+
+// ConcertCriteria returns a copy of the filter keeping only the concert criteria
+func (f Filter) ConcertCriteria() Filter {
+	return Filter{
+		Locations:   f.Locations,
+		Countries:   f.Countries,
+		Continents:  f.Continents,
+		Near:        f.Near,
+		NearLat:     f.NearLat,
+		NearLon:     f.NearLon,
+		RadiusKm:    f.RadiusKm,
+		Origin:      f.Origin,
+		ConcertFrom: f.ConcertFrom,
+		ConcertTo:   f.ConcertTo,
+	}
+}
+
+// ConcertFromValue formats the start of the date window for forms ("" if open)
+func (f Filter) ConcertFromValue() string {
+	return formatDate(f.ConcertFrom)
+}
+
+// ConcertToValue formats the end of the date window for forms ("" if open)
+func (f Filter) ConcertToValue() string {
+	return formatDate(f.ConcertTo)
+}
+
+// HasMemberCount reports whether a member count is selected
+func (f Filter) HasMemberCount(count int) bool {
+	for _, c := range f.MemberCounts {
+		if c == count {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLocation reports whether a location value is selected
+func (f Filter) HasLocation(value string) bool {
+	return containsString(f.Locations, value)
+}
+
+// HasCountry reports whether a country is selected
+func (f Filter) HasCountry(country string) bool {
+	return containsString(f.Countries, country)
+}
+
+// HasContinent reports whether a continent is selected
+func (f Filter) HasContinent(continent string) bool {
+	return containsString(f.Continents, continent)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ArtistPredicates returns the artist-level criteria
+func (f Filter) ArtistPredicates() []ArtistPredicate {
+	var predicates []ArtistPredicate
+	if f.CreationYearMin > 0 || f.CreationYearMax > 0 {
+		predicates = append(predicates, CreationYear(f.CreationYearMin, f.CreationYearMax))
+	}
+	if f.AlbumYearMin > 0 || f.AlbumYearMax > 0 {
+		predicates = append(predicates, FirstAlbumYear(f.AlbumYearMin, f.AlbumYearMax))
+	}
+	if len(f.MemberCounts) > 0 {
+		predicates = append(predicates, MemberCount(f.MemberCounts...))
+	}
+	if f.Query != "" {
+		predicates = append(predicates, TextQuery(f.Query))
+	}
+	if f.Where != nil {
+		predicates = append(predicates, f.Where)
+	}
+	return predicates
+}
+
+// ConcertPredicates returns the concert-level criteria
+func (f Filter) ConcertPredicates(geocoder *geo.Geocoder) []ConcertPredicate {
+	var predicates []ConcertPredicate
+	if f.HasPlaces() {
+		// Places form one hierarchy: a concert in any selected continent, country or city is kept
+		var places []ConcertPredicate
+		if len(f.Continents) > 0 {
+			places = append(places, InContinent(f.Continents...))
+		}
+		if len(f.Countries) > 0 {
+			places = append(places, InCountry(f.Countries...))
+		}
+		if len(f.Locations) > 0 {
+			places = append(places, AtLocation(f.Locations...))
+		}
+		predicates = append(predicates, AnyConcert(places...))
+	}
+	if f.Origin != nil {
+		predicates = append(predicates, WithinRadius(geocoder, *f.Origin, f.RadiusKm))
+	}
+	if !f.ConcertFrom.IsZero() || !f.ConcertTo.IsZero() {
+		predicates = append(predicates, Between(f.ConcertFrom, f.ConcertTo))
+	}
+	return predicates
+}
+
+// Encode serializes the filter back to query-string values, omitting defaults
+func (f Filter) Encode() url.Values {
+	values := url.Values{}
+	setInt := func(key string, value int) {
+		if value > 0 {
+			values.Set(key, strconv.Itoa(value))
+		}
+	}
+
+	setInt(ParamCreationYearMin, f.CreationYearMin)
+	setInt(ParamCreationYearMax, f.CreationYearMax)
+	setInt(ParamAlbumYearMin, f.AlbumYearMin)
+	setInt(ParamAlbumYearMax, f.AlbumYearMax)
+
+	counts := append([]int(nil), f.MemberCounts...)
+	sort.Ints(counts)
+	for _, count := range counts {
+		values.Add(ParamMemberCount, strconv.Itoa(count))
+	}
+	for _, continent := range f.Continents {
+		values.Add(ParamContinent, continent)
+	}
+	for _, country := range f.Countries {
+		values.Add(ParamCountry, country)
+	}
+	for _, location := range f.Locations {
+		values.Add(ParamLocation, location)
+	}
+	if f.Query != "" {
+		values.Set(ParamQuery, f.Query)
+	}
+
+	if f.NearLat != "" || f.NearLon != "" {
+		values.Set(ParamNearLat, f.NearLat)
+		values.Set(ParamNearLon, f.NearLon)
+	} else if f.Near != "" {
+		values.Set(ParamNear, f.Near)
+	}
+	if (f.Near != "" || f.NearLat != "") && f.RadiusKm != DefaultRadiusKm && f.RadiusKm > 0 {
+		values.Set(ParamRadiusKm, strconv.FormatFloat(f.RadiusKm, 'f', -1, 64))
+	}
+
+	if !f.ConcertFrom.IsZero() {
+		values.Set(ParamConcertFrom, formatDate(f.ConcertFrom))
+	}
+	if !f.ConcertTo.IsZero() {
+		values.Set(ParamConcertTo, formatDate(f.ConcertTo))
+	}
+	return values
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(DateLayout)
+}
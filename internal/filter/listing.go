@@ -0,0 +1,216 @@
+package filter
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Sort keys
+const (
+	SortName      = "name"
+	SortCreation  = "creation"
+	SortAlbum     = "album"
+	SortMembers   = "members"
+	SortConcerts  = "concerts"  // Number of matching concerts
+	SortRecent    = "recent"    // Date of the last matching concert
+	SortFavorites = "favorites" // Number of users who favorited the artist
+)
+
+// Listing parameter names and bounds
+const (
+	ParamSort  = "sort"
+	ParamOrder = "order"
+	ParamPage  = "page"
+	ParamLimit = "limit"
+
+	DefaultLimit = 24
+	MaxLimit     = 100
+)
+
+// SortOption describes a sort key for the templates
+type SortOption struct {
+	Value string
+	Label string
+	Desc  bool // Default direction
+}
+
+// SortOptions lists the sort keys in display order
+var SortOptions = []SortOption{
+	{Value: SortName, Label: "Nom"},
+	{Value: SortCreation, Label: "Date de création"},
+	{Value: SortAlbum, Label: "Premier album"},
+	{Value: SortMembers, Label: "Nombre de membres"},
+	{Value: SortConcerts, Label: "Nombre de concerts", Desc: true},
+	{Value: SortRecent, Label: "Concert le plus récent", Desc: true},
+	{Value: SortFavorites, Label: "Favoris", Desc: true},
+}
+
+// Listing holds the sort and pagination of a result list
+type Listing struct {
+	Sort  string // "" keeps the catalog order (or the distance order of a "near me" search)
+	Desc  bool
+	Page  int // From 1
+	Limit int
+}
+
+// ParseListing reads the sort and pagination parameters
+func ParseListing(values url.Values) (Listing, Errors) {
+	listing := Listing{Page: 1, Limit: DefaultLimit}
+	var errs Errors
+
+	if raw := strings.TrimSpace(values.Get(ParamSort)); raw != "" {
+		option, ok := sortOption(raw)
+		if !ok {
+			errs.add(ParamSort, "Tri inconnu : %q", raw)
+		} else {
+			listing.Sort = option.Value
+			listing.Desc = option.Desc
+		}
+	}
+
+	switch raw := strings.TrimSpace(values.Get(ParamOrder)); raw {
+	case "":
+	case "asc":
+		listing.Desc = false
+	case "desc":
+		listing.Desc = true
+	default:
+		errs.add(ParamOrder, "Ordre invalide : %q (asc ou desc)", raw)
+	}
+
+	if raw := strings.TrimSpace(values.Get(ParamPage)); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			errs.add(ParamPage, "Page invalide : %q", raw)
+		} else {
+			listing.Page = page
+		}
+	}
+
+	if raw := strings.TrimSpace(values.Get(ParamLimit)); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			errs.add(ParamLimit, "Limite invalide : %q (entre 1 et %d)", raw, MaxLimit)
+		} else {
+			listing.Limit = limit
+		}
+	}
+
+	return listing, errs
+}
+
+// Order returns "asc" or "desc"
+func (l Listing) Order() string {
+	if l.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// SortMatches orders matches in place. favoriteCounts is only read when sorting by favorites.
+// Ties are broken by name so pages stay stable.
+func (l Listing) SortMatches(matches []Match, favoriteCounts map[int]int) {
+	if l.Sort == "" {
+		return
+	}
+
+	key := func(m Match) int64 {
+		switch l.Sort {
+		case SortCreation:
+			return int64(m.Artist.CreationDate)
+		case SortAlbum:
+			date, err := time.Parse(util.ConcertDateLayout, strings.TrimSpace(m.Artist.FirstAlbum))
+			if err != nil {
+				return 0
+			}
+			return date.Unix()
+		case SortMembers:
+			return int64(len(m.Artist.Members))
+		case SortConcerts:
+			return int64(len(m.Concerts))
+		case SortRecent:
+			if len(m.Concerts) == 0 {
+				return 0
+			}
+			return m.Concerts[len(m.Concerts)-1].Date.Unix()
+		case SortFavorites:
+			return int64(favoriteCounts[m.Artist.ID])
+		}
+		return 0
+	}
+
+	names := make(map[int]string, len(matches))
+	for _, m := range matches {
+		names[m.Artist.ID] = util.FoldText(m.Artist.Name)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if l.Sort != SortName {
+			if ka, kb := key(a), key(b); ka != kb {
+				if l.Desc {
+					return ka > kb
+				}
+				return ka < kb
+			}
+		}
+		na, nb := names[a.Artist.ID], names[b.Artist.ID]
+		if l.Desc && l.Sort == SortName {
+			return na > nb
+		}
+		return na < nb
+	})
+}
+
+// Page is a window of a result list
+type Page struct {
+	Number     int
+	Limit      int
+	Total      int // Results across all pages
+	TotalPages int
+	Start      int // Index of the first result of the page
+	End        int // Index after the last result of the page
+}
+
+// Paginate computes the page of total results. A page past the end is clamped to the last one.
+func (l Listing) Paginate(total int) Page {
+	page := Page{Number: l.Page, Limit: l.Limit, Total: total}
+	page.TotalPages = (total + l.Limit - 1) / l.Limit
+	if page.TotalPages == 0 {
+		page.TotalPages = 1
+	}
+	if page.Number > page.TotalPages {
+		page.Number = page.TotalPages
+	}
+
+	page.Start = (page.Number - 1) * l.Limit
+	page.End = page.Start + l.Limit
+	if page.End > total {
+		page.End = total
+	}
+	return page
+}
+
+// HasPrev reports whether there is a page before this one
+func (p Page) HasPrev() bool {
+	return p.Number > 1
+}
+
+// HasNext reports whether there is a page after this one
+func (p Page) HasNext() bool {
+	return p.Number < p.TotalPages
+}
+
+func sortOption(value string) (SortOption, bool) {
+	for _, option := range SortOptions {
+		if option.Value == value {
+			return option, true
+		}
+	}
+	return SortOption{}, false
+}
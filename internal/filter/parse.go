@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Bounds accepted for years
+const (
+	minYear = 1900
+	maxYear = 2100
+)
+
+// maxNearLength bounds the "near" place name
+const maxNearLength = 100
+
+// ValidationError reports one invalid parameter
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"` // French, shown to the user
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Errors lists every invalid parameter of a query
+type Errors []ValidationError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Get returns the message for a field ("" if the field is valid)
+func (e Errors) Get(field string) string {
+	for _, err := range e {
+		if err.Field == field {
+			return err.Message
+		}
+	}
+	return ""
+}
+
+func (e *Errors) add(field, format string, args ...any) {
+	*e = append(*e, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Parse reads a filter from query-string values. Invalid parameters are ignored
+// and reported in the returned Errors (nil when everything is valid).
+// A "near" place name is kept as typed, ResolveNear looks it up.
+func Parse(values url.Values) (Filter, Errors) {
+	var f Filter
+	var errs Errors
+
+	f.CreationYearMin, f.CreationYearMax = parseYearRange(values, ParamCreationYearMin, ParamCreationYearMax, &errs)
+	f.AlbumYearMin, f.AlbumYearMax = parseYearRange(values, ParamAlbumYearMin, ParamAlbumYearMax, &errs)
+
+	seen := make(map[int]bool)
+	for _, raw := range values[ParamMemberCount] {
+		count, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || count <= 0 {
+			errs.add(ParamMemberCount, "Nombre de membres invalide : %q", raw)
+			continue
+		}
+		if !seen[count] {
+			seen[count] = true
+			f.MemberCounts = append(f.MemberCounts, count)
+		}
+	}
+	sort.Ints(f.MemberCounts)
+
+	for _, raw := range values[ParamLocation] {
+		if location := strings.TrimSpace(raw); location != "" {
+			f.Locations = append(f.Locations, location)
+		}
+	}
+	for _, raw := range values[ParamCountry] {
+		if strings.TrimSpace(raw) != "" {
+			f.Countries = appendUnique(f.Countries, util.CountryName(raw))
+		}
+	}
+	for _, raw := range values[ParamContinent] {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		continent := util.ContinentName(raw)
+		if continent == "" {
+			errs.add(ParamContinent, "Continent inconnu : %q", raw)
+			continue
+		}
+		f.Continents = appendUnique(f.Continents, continent)
+	}
+
+	f.Query = strings.TrimSpace(values.Get(ParamQuery))
+
+	parseNear(values, &f, &errs)
+
+	f.ConcertFrom = parseDate(values, ParamConcertFrom, &errs)
+	f.ConcertTo = parseDate(values, ParamConcertTo, &errs)
+	if !f.ConcertFrom.IsZero() && !f.ConcertTo.IsZero() && f.ConcertFrom.After(f.ConcertTo) {
+		errs.add(ParamConcertFrom, "La date de début (%s) est après la date de fin (%s)", f.ConcertFromValue(), f.ConcertToValue())
+		f.ConcertFrom, f.ConcertTo = time.Time{}, time.Time{}
+	}
+	return f, errs
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// parseDate reads an optional date, as "2019-06-21" or in the API format "21-06-2019"
+func parseDate(values url.Values, key string, errs *Errors) time.Time {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{DateLayout, util.ConcertDateLayout} {
+		if date, err := time.Parse(layout, raw); err == nil {
+			return date
+		}
+	}
+	errs.add(key, "Date invalide : %q (format AAAA-MM-JJ)", raw)
+	return time.Time{}
+}
+
+// parseYearRange reads an optional min/max pair of years
+func parseYearRange(values url.Values, minKey, maxKey string, errs *Errors) (int, int) {
+	min := parseYear(values, minKey, errs)
+	max := parseYear(values, maxKey, errs)
+	if min > 0 && max > 0 && min > max {
+		errs.add(minKey, "L'année minimale (%d) dépasse l'année maximale (%d)", min, max)
+		return 0, 0
+	}
+	return min, max
+}
+
+func parseYear(values url.Values, key string, errs *Errors) int {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return 0
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil || year < minYear || year > maxYear {
+		errs.add(key, "Année invalide : %q (entre %d et %d)", raw, minYear, maxYear)
+		return 0
+	}
+	return year
+}
+
+// parseNear reads the "near me" center and radius. Coordinates set Origin, a place name is left to ResolveNear.
+func parseNear(values url.Values, f *Filter, errs *Errors) {
+	f.Near = strings.TrimSpace(values.Get(ParamNear))
+	f.NearLat = strings.TrimSpace(values.Get(ParamNearLat))
+	f.NearLon = strings.TrimSpace(values.Get(ParamNearLon))
+
+	f.RadiusKm = DefaultRadiusKm
+	if raw := strings.TrimSpace(values.Get(ParamRadiusKm)); raw != "" {
+		radius, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(radius) || radius < MinRadiusKm || radius > MaxRadiusKm {
+			errs.add(ParamRadiusKm, "Rayon invalide : %q (entre %.0f et %.0f km)", raw, MinRadiusKm, MaxRadiusKm)
+		} else {
+			f.RadiusKm = radius
+		}
+	}
+
+	if f.NearLat != "" || f.NearLon != "" {
+		lat, errLat := strconv.ParseFloat(f.NearLat, 64)
+		lon, errLon := strconv.ParseFloat(f.NearLon, 64)
+		if errLat != nil || errLon != nil || math.IsNaN(lat) || math.IsNaN(lon) || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+			errs.add(ParamNear, "Coordonnées invalides")
+			return
+		}
+		f.Origin = &geo.Coord{Lat: lat, Lon: lon}
+		return
+	}
+
+	if len(f.Near) > maxNearLength {
+		errs.add(ParamNear, "Lieu trop long (%d caractères max)", maxNearLength)
+		f.Near = ""
+	}
+}
+
+// ResolveNear sets Origin from the "near" place name. Only the gazetteer and the cache
+// of geocoder are read, so parsing a request never waits for the remote provider.
+func (f *Filter) ResolveNear(geocoder *geo.Geocoder) Errors {
+	if f.Near == "" || f.NearLat != "" || f.NearLon != "" {
+		return nil
+	}
+
+	coord, ok := geocoder.Search(f.Near)
+	if !ok {
+		var errs Errors
+		errs.add(ParamNear, "Lieu introuvable : %s", f.Near)
+		return errs
+	}
+	f.Origin = &coord
+	return nil
+}
@@ -0,0 +1,176 @@
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// ArtistPredicate decides whether an artist is kept
+type ArtistPredicate func(artist util.Artist) bool
+
+// ConcertPredicate decides whether a concert is kept.
+// An artist is kept only if one of its concerts satisfies every concert predicate.
+type ConcertPredicate func(concert util.Concert) bool
+
+// AllArtists keeps the artists matching every predicate (all of them if there is none)
+func AllArtists(predicates ...ArtistPredicate) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		for _, predicate := range predicates {
+			if !predicate(artist) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyArtist keeps the artists matching at least one predicate
+func AnyArtist(predicates ...ArtistPredicate) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		for _, predicate := range predicates {
+			if predicate(artist) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NotArtist inverts a predicate
+func NotArtist(predicate ArtistPredicate) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		return !predicate(artist)
+	}
+}
+
+// AllConcerts keeps the concerts matching every predicate (all of them if there is none)
+func AllConcerts(predicates ...ConcertPredicate) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		for _, predicate := range predicates {
+			if !predicate(concert) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyConcert keeps the concerts matching at least one predicate
+func AnyConcert(predicates ...ConcertPredicate) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		for _, predicate := range predicates {
+			if predicate(concert) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CreationYear keeps artists created between min and max; a zero bound is open
+func CreationYear(min, max int) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		return inRange(artist.CreationDate, min, max)
+	}
+}
+
+// FirstAlbumYear keeps artists whose first album came out between min and max.
+// Artists without a readable album date are kept.
+func FirstAlbumYear(min, max int) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		year, ok := AlbumYear(artist)
+		return !ok || inRange(year, min, max)
+	}
+}
+
+// MemberCount keeps artists with one of the given numbers of members
+func MemberCount(counts ...int) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		for _, count := range counts {
+			if len(artist.Members) == count {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TextQuery keeps artists whose name or one of whose members matches query,
+// ignoring case and accents and tolerating typos (see util.MatchArtist)
+func TextQuery(query string) ArtistPredicate {
+	return func(artist util.Artist) bool {
+		_, ok := util.MatchArtist(artist, query)
+		return ok
+	}
+}
+
+// AtLocation keeps concerts held at a location designated by one of the values
+// (slug, "City, Country", city, region or country, see util.Location.Matches)
+func AtLocation(values ...string) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		for _, value := range values {
+			if concert.Location.Matches(value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// InCountry keeps concerts held in one of the countries (display names)
+func InCountry(countries ...string) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		return containsString(countries, concert.Location.Country)
+	}
+}
+
+// InContinent keeps concerts held on one of the continents (display names)
+func InContinent(continents ...string) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		return containsString(continents, concert.Location.Continent)
+	}
+}
+
+// WithinRadius keeps concerts held less than radiusKm away from origin.
+// Locations only known by their country are dropped, their distance would be meaningless.
+func WithinRadius(geocoder *geo.Geocoder, origin geo.Coord, radiusKm float64) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		result := geocoder.Lookup(concert.Location)
+		return result.Found && !result.Approximate() && geo.DistanceKm(origin, result.Coord) <= radiusKm
+	}
+}
+
+// Between keeps concerts held between from and to, inclusive; a zero bound is open
+func Between(from, to time.Time) ConcertPredicate {
+	return func(concert util.Concert) bool {
+		if !from.IsZero() && concert.Date.Before(from) {
+			return false
+		}
+		if !to.IsZero() && concert.Date.After(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// AlbumYear returns the year of an artist's first album ("14-03-1973")
+func AlbumYear(artist util.Artist) (int, bool) {
+	date, err := time.Parse(util.ConcertDateLayout, strings.TrimSpace(artist.FirstAlbum))
+	if err != nil {
+		return 0, false
+	}
+	return date.Year(), true
+}
+
+func inRange(value, min, max int) bool {
+	if min > 0 && value < min {
+		return false
+	}
+	if max > 0 && value > max {
+		return false
+	}
+	return true
+}
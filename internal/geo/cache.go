@@ -0,0 +1,107 @@
+package geo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a remote geocoding result saved on disk
+type CacheEntry struct {
+	Coord
+	Found      bool      `json:"found"` // false: the provider knows no such place
+	Query      string    `json:"query"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// Cache persists remote geocoding results, keyed by location slug
+type Cache struct {
+	filename string
+	entries  map[string]CacheEntry
+	dirty    bool // Entries were added since the file was last written
+	mutex    sync.RWMutex
+}
+
+// OpenCache loads the cache file, starting empty if it does not exist yet
+func OpenCache(filename string) (*Cache, error) {
+	c := &Cache{
+		filename: filename,
+		entries:  make(map[string]CacheEntry),
+	}
+
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the cached result for a slug
+func (c *Cache) Get(slug string) (CacheEntry, bool) {
+	if c == nil {
+		return CacheEntry{}, false
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, ok := c.entries[slug]
+	return entry, ok
+}
+
+// Put stores a result in memory; Flush writes it to the file
+func (c *Cache) Put(slug string, entry CacheEntry) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[slug] = entry
+	c.dirty = true
+}
+
+// Flush rewrites the cache file if results were added since the last write
+func (c *Cache) Flush() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *Cache) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(c.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.entries)
+}
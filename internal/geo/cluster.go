@@ -0,0 +1,102 @@
+package geo
+
+import "math"
+
+// Clustering parameters
+const (
+	ClusterCellPx  = 60 // Size of a grid cell in screen pixels
+	MaxClusterZoom = 10 // Every location is shown on its own beyond this zoom
+	MaxZoom        = 19
+	tileSizePx     = 256
+)
+
+// ClusterProperties describes several concert locations merged into one marker
+type ClusterProperties struct {
+	Cluster   bool     `json:"cluster"` // Always true, tells clusters from single locations
+	Locations int      `json:"locations"`
+	Concerts  int      `json:"concerts"`
+	ArtistIDs []int    `json:"artist_ids"`
+	Artists   []string `json:"artists"` // Same order as ArtistIDs
+	Names     []string `json:"names"`   // Location names
+}
+
+type clusterCell struct {
+	x, y int
+}
+
+// ClusterFeatures merges the concert points falling in the same grid cell at the given zoom.
+// Cells are ClusterCellPx wide on the Web Mercator projection used by the map tiles.
+// Cells holding one location keep their original feature.
+func ClusterFeatures(collection FeatureCollection, zoom int) FeatureCollection {
+	if zoom > MaxClusterZoom {
+		return collection
+	}
+
+	scale := tileSizePx * math.Exp2(float64(zoom))
+	groups := make(map[clusterCell][]Feature)
+	var cells []clusterCell // First appearance order, keeps the output stable
+
+	for _, feature := range collection.Features {
+		x, y := project(feature.Geometry.Coordinates[1], feature.Geometry.Coordinates[0], scale)
+		cell := clusterCell{x: int(x / ClusterCellPx), y: int(y / ClusterCellPx)}
+		if _, ok := groups[cell]; !ok {
+			cells = append(cells, cell)
+		}
+		groups[cell] = append(groups[cell], feature)
+	}
+
+	clustered := NewFeatureCollection()
+	for _, cell := range cells {
+		features := groups[cell]
+		if len(features) == 1 {
+			clustered.Features = append(clustered.Features, features[0])
+			continue
+		}
+		clustered.Features = append(clustered.Features, mergeFeatures(features))
+	}
+	return clustered
+}
+
+// mergeFeatures builds a cluster placed at the concert-weighted centroid of its locations
+func mergeFeatures(features []Feature) Feature {
+	properties := &ClusterProperties{Cluster: true}
+	var lat, lon, weight float64
+
+	for _, feature := range features {
+		concert, ok := feature.Properties.(*ConcertProperties)
+		if !ok {
+			continue
+		}
+
+		w := float64(concert.Concerts)
+		lon += feature.Geometry.Coordinates[0] * w
+		lat += feature.Geometry.Coordinates[1] * w
+		weight += w
+
+		properties.Locations++
+		properties.Concerts += concert.Concerts
+		properties.Names = append(properties.Names, concert.Name)
+		for i, id := range concert.ArtistIDs {
+			if !containsInt(properties.ArtistIDs, id) {
+				properties.ArtistIDs = append(properties.ArtistIDs, id)
+				properties.Artists = append(properties.Artists, concert.Artists[i])
+			}
+		}
+	}
+
+	if weight == 0 {
+		weight = 1
+	}
+	return NewPointFeature(Coord{Lat: lat / weight, Lon: lon / weight}, properties)
+}
+
+// project converts a point to Web Mercator pixels at the given world size
+func project(lat, lon, scale float64) (x, y float64) {
+	// Mercator is undefined at the poles
+	lat = math.Max(-85.05112878, math.Min(85.05112878, lat))
+	sin := math.Sin(lat * math.Pi / 180)
+
+	x = (lon + 180) / 360 * scale
+	y = (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * scale
+	return x, y
+}
@@ -0,0 +1,127 @@
+package geo
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// GazetteerEntry is a named place with its coordinates
+type GazetteerEntry struct {
+	Name    string  `json:"name"`
+	Country string  `json:"country,omitempty"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// gazetteerFile is the JSON layout of the bundled gazetteer
+type gazetteerFile struct {
+	Places    []GazetteerEntry `json:"places"`    // Cities
+	Regions   []GazetteerEntry `json:"regions"`   // States and provinces
+	Countries []GazetteerEntry `json:"countries"` // Country centroids, used as a last resort
+}
+
+// Gazetteer resolves place names offline
+type Gazetteer struct {
+	places    map[string]Coord // "city|country" -> coordinates
+	regions   map[string]Coord // "region|country" -> coordinates
+	countries map[string]Coord // "country" -> centroid
+
+	placesByName  map[string][]GazetteerEntry // "city" -> entries in every country
+	regionsByName map[string][]GazetteerEntry // "region" -> entries in every country
+}
+
+// LoadGazetteer reads a gazetteer JSON file
+func LoadGazetteer(filename string) (*Gazetteer, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var data gazetteerFile
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	g := &Gazetteer{
+		places:    make(map[string]Coord, len(data.Places)),
+		regions:   make(map[string]Coord, len(data.Regions)),
+		countries: make(map[string]Coord, len(data.Countries)),
+
+		placesByName:  make(map[string][]GazetteerEntry, len(data.Places)),
+		regionsByName: make(map[string][]GazetteerEntry, len(data.Regions)),
+	}
+	for _, entry := range data.Places {
+		g.places[placeKey(entry.Name, entry.Country)] = Coord{Lat: entry.Lat, Lon: entry.Lon}
+		name := util.FoldText(entry.Name)
+		g.placesByName[name] = append(g.placesByName[name], entry)
+	}
+	for _, entry := range data.Regions {
+		g.regions[placeKey(entry.Name, entry.Country)] = Coord{Lat: entry.Lat, Lon: entry.Lon}
+		name := util.FoldText(entry.Name)
+		g.regionsByName[name] = append(g.regionsByName[name], entry)
+	}
+	for _, entry := range data.Countries {
+		g.countries[util.FoldText(entry.Name)] = Coord{Lat: entry.Lat, Lon: entry.Lon}
+	}
+	return g, nil
+}
+
+// Lookup finds a location by city, then region. It does not fall back to the country.
+func (g *Gazetteer) Lookup(location util.Location) (Coord, bool) {
+	if g == nil {
+		return Coord{}, false
+	}
+
+	if location.City != "" {
+		if coord, ok := g.places[placeKey(location.City, location.Country)]; ok {
+			return coord, true
+		}
+	}
+	if location.Region != "" {
+		if coord, ok := g.regions[placeKey(location.Region, location.Country)]; ok {
+			return coord, true
+		}
+	}
+	return Coord{}, false
+}
+
+// FindName looks up a free-text place name: a city, then a region, then a country.
+// country is optional and narrows homonyms ("Paris" in France rather than in Texas);
+// without it the first entry of the file wins.
+func (g *Gazetteer) FindName(name, country string) (Coord, bool) {
+	if g == nil {
+		return Coord{}, false
+	}
+
+	name = util.FoldText(name)
+	country = util.FoldText(country)
+	for _, entries := range [][]GazetteerEntry{g.placesByName[name], g.regionsByName[name]} {
+		for _, entry := range entries {
+			if country == "" || util.FoldText(entry.Country) == country {
+				return Coord{Lat: entry.Lat, Lon: entry.Lon}, true
+			}
+		}
+	}
+
+	if country == "" {
+		coord, ok := g.countries[name]
+		return coord, ok
+	}
+	return Coord{}, false
+}
+
+// Country returns the centroid of a country
+func (g *Gazetteer) Country(name string) (Coord, bool) {
+	if g == nil {
+		return Coord{}, false
+	}
+	coord, ok := g.countries[util.FoldText(name)]
+	return coord, ok
+}
+
+func placeKey(name, country string) string {
+	return util.FoldText(name) + "|" + util.FoldText(country)
+}
@@ -0,0 +1,24 @@
+package geo
+
+import "math"
+
+// earthRadiusKm is the mean Earth radius used for distances
+const earthRadiusKm = 6371.0
+
+// Coord is a point on Earth in decimal degrees
+type Coord struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// DistanceKm returns the great-circle distance between two points (haversine formula)
+func DistanceKm(a, b Coord) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,134 @@
+package geo
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Result sources
+const (
+	SourceGazetteer = "gazetteer"
+	SourceCache     = "cache"
+	SourceRemote    = "remote"
+	SourceCountry   = "country" // Country centroid: the place itself is unknown
+)
+
+// notFoundRetry is how long a place the provider did not know is left alone
+const notFoundRetry = 7 * 24 * time.Hour
+
+// cacheFlushInterval is how often Warm saves the places it resolved so far
+const cacheFlushInterval = time.Minute
+
+// Result is the outcome of a geocoding lookup
+type Result struct {
+	Coord
+	Found  bool
+	Source string
+}
+
+// Approximate reports whether only the country could be located
+func (r Result) Approximate() bool {
+	return r.Source == SourceCountry
+}
+
+// Geocoder resolves concert locations: gazetteer first, then the persistent
+// cache of remote results, then the remote provider if any, then the country centroid
+type Geocoder struct {
+	gazetteer *Gazetteer
+	cache     *Cache
+	provider  Provider // Optional
+	warming   atomic.Bool
+}
+
+// Default is the global geocoder, replaced at startup by app.Start
+var Default = NewGeocoder(nil, nil, nil)
+
+// NewGeocoder creates a geocoder; every argument may be nil
+func NewGeocoder(gazetteer *Gazetteer, cache *Cache, provider Provider) *Geocoder {
+	return &Geocoder{
+		gazetteer: gazetteer,
+		cache:     cache,
+		provider:  provider,
+	}
+}
+
+// Lookup returns the coordinates of a concert location from the gazetteer and the cache.
+// It never calls the provider, so request handlers use it; places not resolved yet
+// by Warm fall back to their country.
+func (g *Geocoder) Lookup(location util.Location) Result {
+	return g.resolve(location, false)
+}
+
+// Resolve is Lookup, but asks the provider for places not cached yet.
+// It can block on the network, so only Warm calls it.
+func (g *Geocoder) Resolve(location util.Location) Result {
+	return g.resolve(location, true)
+}
+
+func (g *Geocoder) resolve(location util.Location, remote bool) Result {
+	if coord, ok := g.gazetteer.Lookup(location); ok {
+		return Result{Coord: coord, Found: true, Source: SourceGazetteer}
+	}
+
+	entry, cached := g.cache.Get(location.Slug)
+	if cached && entry.Found {
+		return Result{Coord: entry.Coord, Found: true, Source: SourceCache}
+	}
+
+	if remote && g.provider != nil && (!cached || time.Since(entry.ResolvedAt) > notFoundRetry) {
+		if result, ok := g.resolveRemote(location); ok {
+			return result
+		}
+	}
+
+	if coord, ok := g.gazetteer.Country(location.Country); ok {
+		return Result{Coord: coord, Found: true, Source: SourceCountry}
+	}
+	return Result{}
+}
+
+func (g *Geocoder) resolveRemote(location util.Location) (Result, bool) {
+	query := location.Query()
+	coord, found, err := g.provider.Geocode(query)
+	if err != nil {
+		// Network errors are not cached so the place is retried later
+		log.Printf("Geocoding %q failed: %v\n", query, err)
+		return Result{}, false
+	}
+
+	g.cache.Put(location.Slug, CacheEntry{Coord: coord, Found: found, Query: query, ResolvedAt: time.Now()})
+
+	if !found {
+		return Result{}, false
+	}
+	return Result{Coord: coord, Found: true, Source: SourceRemote}, true
+}
+
+// Warm resolves every location once so page views hit the cache.
+// The cache file is written every cacheFlushInterval and at the end, not for each place.
+// Concurrent calls are ignored while a warm-up is running.
+func (g *Geocoder) Warm(locations []util.Location) {
+	if !g.warming.CompareAndSwap(false, true) {
+		return
+	}
+	defer g.warming.Store(false)
+	defer g.flushCache()
+
+	lastFlush := time.Now()
+	for _, location := range locations {
+		g.Resolve(location)
+		if time.Since(lastFlush) >= cacheFlushInterval {
+			g.flushCache()
+			lastFlush = time.Now()
+		}
+	}
+}
+
+func (g *Geocoder) flushCache() {
+	if err := g.cache.Flush(); err != nil {
+		log.Println("Geocoding cache not saved:", err)
+	}
+}
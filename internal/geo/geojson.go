@@ -0,0 +1,124 @@
+package geo
+
+import (
+	"sort"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// ISODateLayout is the date format used in GeoJSON properties
+const ISODateLayout = "2006-01-02"
+
+// FeatureCollection is a GeoJSON feature collection (RFC 7946)
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature
+type Feature struct {
+	Type       string   `json:"type"`
+	Geometry   Geometry `json:"geometry"`
+	Properties any      `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Only points are produced.
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // [longitude, latitude]
+}
+
+// NewFeatureCollection returns an empty collection
+func NewFeatureCollection() FeatureCollection {
+	return FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+// NewPointFeature returns a point feature at coord
+func NewPointFeature(coord Coord, properties any) Feature {
+	return Feature{
+		Type: "Feature",
+		Geometry: Geometry{
+			Type:        "Point",
+			Coordinates: []float64{coord.Lon, coord.Lat},
+		},
+		Properties: properties,
+	}
+}
+
+// ConcertProperties describes the concerts held at one location
+type ConcertProperties struct {
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Country     string   `json:"country"`
+	Approximate bool     `json:"approximate"` // Placed at the country centroid
+	ArtistIDs   []int    `json:"artist_ids"`
+	Artists     []string `json:"artists"` // Same order as ArtistIDs
+	Dates       []string `json:"dates"`   // ISODateLayout, sorted
+	Concerts    int      `json:"concerts"`
+}
+
+// ConcertFeatures builds one point per concert location.
+// artistNames maps artist IDs to names; locations that cannot be resolved are left out.
+func (g *Geocoder) ConcertFeatures(concerts []util.Concert, artistNames map[int]string) FeatureCollection {
+	collection := NewFeatureCollection()
+
+	bySlug := make(map[string]*ConcertProperties)
+	results := make(map[string]Result)
+	var slugs []string
+
+	for _, concert := range concerts {
+		slug := concert.Location.Slug
+		properties, ok := bySlug[slug]
+		if !ok {
+			result := g.Lookup(concert.Location)
+			if !result.Found {
+				continue
+			}
+			properties = &ConcertProperties{
+				Slug:        slug,
+				Name:        concert.Location.String(),
+				Country:     concert.Location.Country,
+				Approximate: result.Approximate(),
+			}
+			bySlug[slug] = properties
+			results[slug] = result
+			slugs = append(slugs, slug)
+		}
+
+		if !containsInt(properties.ArtistIDs, concert.ArtistID) {
+			properties.ArtistIDs = append(properties.ArtistIDs, concert.ArtistID)
+			properties.Artists = append(properties.Artists, artistNames[concert.ArtistID])
+		}
+		properties.Dates = append(properties.Dates, concert.Date.Format(ISODateLayout))
+		properties.Concerts++
+	}
+
+	sort.Strings(slugs)
+	for _, slug := range slugs {
+		properties := bySlug[slug]
+		properties.Dates = sortedUnique(properties.Dates)
+		collection.Features = append(collection.Features, NewPointFeature(results[slug].Coord, properties))
+	}
+	return collection
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedUnique sorts values and removes duplicates in place
+func sortedUnique(values []string) []string {
+	sort.Strings(values)
+	unique := values[:0]
+	for _, value := range values {
+		if len(unique) == 0 || value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
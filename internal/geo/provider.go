@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Provider resolves a free-text place name through a remote service
+type Provider interface {
+	Geocode(query string) (Coord, bool, error)
+}
+
+// DefaultNominatimURL is the public OpenStreetMap geocoder
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// NominatimProvider queries a Nominatim server, at most once per second as its usage policy requires
+type NominatimProvider struct {
+	BaseURL   string
+	UserAgent string
+	Client    *http.Client
+
+	mutex    sync.Mutex
+	lastCall time.Time
+}
+
+// NewNominatimProvider creates a provider (DefaultNominatimURL if baseURL is empty)
+func NewNominatimProvider(baseURL string) *NominatimProvider {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	return &NominatimProvider{
+		BaseURL:   baseURL,
+		UserAgent: "groupie-tracker/1.0",
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *NominatimProvider) Geocode(query string) (Coord, bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// 1 request per second
+	if wait := time.Second - time.Since(p.lastCall); wait > 0 {
+		time.Sleep(wait)
+	}
+	defer func() { p.lastCall = time.Now() }()
+
+	req, err := http.NewRequest(http.MethodGet, p.BaseURL+"/search?format=json&limit=1&q="+url.QueryEscape(query), nil)
+	if err != nil {
+		return Coord{}, false, err
+	}
+	req.Header.Set("User-Agent", p.UserAgent)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return Coord{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Coord{}, false, fmt.Errorf("nominatim: unexpected status %s", resp.Status)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return Coord{}, false, err
+	}
+	if len(results) == 0 {
+		return Coord{}, false, nil
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return Coord{}, false, err
+	}
+	lon, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return Coord{}, false, err
+	}
+	return Coord{Lat: lat, Lon: lon}, true, nil
+}
@@ -0,0 +1,29 @@
+package geo
+
+import (
+	"strings"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Search finds a place typed by a user: a location slug ("paris-france"),
+// "City, Country" or a single city, region or country name.
+// Like Lookup it only reads the gazetteer and the cache, never the remote provider.
+func (g *Geocoder) Search(query string) (Coord, bool) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return Coord{}, false
+	}
+
+	if !strings.ContainsAny(query, " ,") && strings.Contains(query, "-") {
+		if result := g.Lookup(util.ParseLocation(query)); result.Found && !result.Approximate() {
+			return result.Coord, true
+		}
+	}
+
+	name, country, _ := strings.Cut(query, ",")
+	if country = strings.TrimSpace(country); country != "" {
+		country = util.CountryName(country)
+	}
+	return g.gazetteer.FindName(strings.TrimSpace(name), country)
+}
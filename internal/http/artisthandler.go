@@ -5,12 +5,16 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
 	"github.com/YajiTV/groupie-tracker/internal/templates"
 	"github.com/YajiTV/groupie-tracker/internal/util"
 )
 
 type ArtistData struct {
-	Artist util.ArtistWithLocations
+	Artist   util.ArtistWithLocations
+	MapURL   string // GeoJSON of the concerts shown, loaded by the map script
+	Filtered bool   // Only the concerts matching the filter parameters are shown
 }
 
 func ArtistHandler(w http.ResponseWriter, r *http.Request) {
@@ -20,12 +24,59 @@ func ArtistHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	artistWithLocations, err := util.FetchArtistWithLocations(id)
+	catalog, err := util.Catalog.Get()
 	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	artistWithLocations, ok := catalog.ArtistWithLocations(id)
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	data := ArtistData{Artist: artistWithLocations}
+	// Coming from a filtered list, keep only the concerts matching the concert criteria
+	filters, _ := parseFilter(r)
+	criteria := filters.ConcertCriteria()
+	if criteria.HasConcertCriteria() {
+		match, _ := filter.NewEngine(catalog, geo.Default).MatchArtist(artistWithLocations.Artist, criteria)
+		artistWithLocations.Locations = keepMatchedConcerts(artistWithLocations.Locations, match.Concerts)
+	}
+
+	mapURL := "/api/artists/" + strconv.Itoa(id) + "/concerts.geojson"
+	if params := criteria.Encode(); len(params) > 0 {
+		mapURL += "?" + params.Encode()
+	}
+
+	data := ArtistData{
+		Artist:   artistWithLocations,
+		MapURL:   mapURL,
+		Filtered: criteria.HasConcertCriteria(),
+	}
 	templates.Templates.ExecuteTemplate(w, "artist.gohtml", data)
 }
+
+// keepMatchedConcerts removes the dates that are not among concerts, then the locations left without dates
+func keepMatchedConcerts(locations []util.ArtistLocation, concerts []util.Concert) []util.ArtistLocation {
+	matched := make(map[string]bool, len(concerts))
+	for _, concert := range concerts {
+		matched[concert.Location.Slug+"|"+concert.Date.Format(util.ConcertDateLayout)] = true
+	}
+
+	var kept []util.ArtistLocation
+	for _, location := range locations {
+		var dates []string
+		for _, raw := range location.Dates {
+			date, err := util.ParseConcertDate(raw)
+			if err == nil && matched[location.Place.Slug+"|"+date.Format(util.ConcertDateLayout)] {
+				dates = append(dates, raw)
+			}
+		}
+		if len(dates) > 0 {
+			location.Dates = dates
+			kept = append(kept, location)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,82 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// ArtistListItem is an artist of the JSON listing with its matching concerts summed up
+type ArtistListItem struct {
+	util.Artist
+	Concerts    int      `json:"concerts"`
+	LastConcert string   `json:"last_concert,omitempty"` // ISO date
+	DistanceKm  *float64 `json:"distance_km,omitempty"`  // Set by a "near me" search
+}
+
+// ArtistList is one page of the JSON listing
+type ArtistList struct {
+	Total      int              `json:"total"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	TotalPages int              `json:"total_pages"`
+	Prev       string           `json:"prev,omitempty"` // URL of the previous page
+	Next       string           `json:"next,omitempty"`
+	Artists    []ArtistListItem `json:"artists"`
+}
+
+// ArtistsAPIHandler lists the artists matching the home filters, sorted and paginated
+// (GET /api/artists?sort=recent&order=desc&page=2&limit=10 plus the filter parameters)
+func ArtistsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	filters, errs := parseFilter(r)
+	listing, listingErrors := filter.ParseListing(r.URL.Query())
+	errs = append(errs, listingErrors...)
+	if errs != nil {
+		sendFilterErrors(w, errs)
+		return
+	}
+
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	matches := filter.NewEngine(catalog, geo.Default).Match(filters)
+
+	// Same default order as the home page
+	var distances map[int]float64
+	if filters.Origin != nil {
+		var sorted []filter.Match
+		sorted, distances = sortMatchesByNearby(matches, findNearbyArtists(matches, *filters.Origin))
+		if listing.Sort == "" {
+			matches = sorted
+		}
+	}
+
+	pageMatches, pagination := listMatches(r, matches, listing)
+
+	list := ArtistList{
+		Total:      pagination.Total,
+		Page:       pagination.Number,
+		Limit:      pagination.Limit,
+		TotalPages: pagination.TotalPages,
+		Prev:       pagination.PrevURL,
+		Next:       pagination.NextURL,
+		Artists:    make([]ArtistListItem, 0, len(pageMatches)),
+	}
+	for _, match := range pageMatches {
+		item := ArtistListItem{Artist: match.Artist, Concerts: len(match.Concerts)}
+		if n := len(match.Concerts); n > 0 {
+			item.LastConcert = match.Concerts[n-1].Date.Format(geo.ISODateLayout)
+		}
+		if distance, ok := distances[match.Artist.ID]; ok {
+			item.DistanceKm = &distance
+		}
+		list.Artists = append(list.Artists, item)
+	}
+
+	sendJSONResponse(w, list)
+}
@@ -1,7 +1,10 @@
 package httphandlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/YajiTV/groupie-tracker/internal/auth"
 	"github.com/YajiTV/groupie-tracker/internal/storage"
@@ -15,13 +18,17 @@ func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		Title   string
-		Error   string
-		Success string
+		Title      string
+		Error      string
+		Success    string
+		RetryAfter string
+		CSRFToken  string
 	}{
-		Title:   "Connexion",
-		Error:   r.URL.Query().Get("error"),
-		Success: r.URL.Query().Get("success"),
+		Title:      "Connexion",
+		Error:      r.URL.Query().Get("error"),
+		Success:    r.URL.Query().Get("success"),
+		RetryAfter: formatRetryAfter(r.URL.Query().Get("retry")),
+		CSRFToken:  auth.CSRFToken(r),
 	}
 
 	templates.Templates.ExecuteTemplate(w, "login.gohtml", data)
@@ -35,10 +42,24 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	username := r.FormValue("username")
 	password := r.FormValue("password")
+	client := auth.ClientFromRequest(r)
 
-	sessionID, err := authenticateUser(username, password)
+	user, err := authenticateUser(username, password, client)
 	if err != nil {
-		http.Redirect(w, r, "/login?error=invalid", http.StatusSeeOther)
+		redirectLoginError(w, r, err)
+		return
+	}
+
+	// Second step: the session is only issued once the code is checked
+	if user.TOTPEnabled {
+		auth.SetChallengeCookie(w, auth.Challenges.Create(user.ID, client))
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	sessionID, err := completeLogin(user, client)
+	if err != nil {
+		redirectLoginError(w, r, err)
 		return
 	}
 
@@ -46,6 +67,21 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/profile", http.StatusSeeOther)
 }
 
+// redirectLoginError sends a failed login back to the login page with its message
+func redirectLoginError(w http.ResponseWriter, r *http.Request, err error) {
+	var throttled *LoginThrottleError
+	if errors.As(err, &throttled) {
+		code := "throttled"
+		if throttled.Locked {
+			code = "locked"
+		}
+		retry := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		http.Redirect(w, r, "/login?error="+code+"&retry="+strconv.Itoa(retry), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/login?error="+getErrorCode(err), http.StatusSeeOther)
+}
+
 func RegisterPageHandler(w http.ResponseWriter, r *http.Request) {
 	if auth.IsAuthenticated(r) {
 		http.Redirect(w, r, "/profile", http.StatusSeeOther)
@@ -53,11 +89,13 @@ func RegisterPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		Title string
-		Error string
+		Title     string
+		Error     string
+		CSRFToken string
 	}{
-		Title: "Inscription",
-		Error: r.URL.Query().Get("error"),
+		Title:     "Inscription",
+		Error:     r.URL.Query().Get("error"),
+		CSRFToken: auth.CSRFToken(r),
 	}
 
 	templates.Templates.ExecuteTemplate(w, "register.gohtml", data)
@@ -83,7 +121,15 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/login?success=registered", http.StatusSeeOther)
 }
 
+// LogoutHandler ends the current session (POST /logout)
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	// State change: POST only, so the CSRF middleware checks it. A GET must not look like it logged out.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		return
+	}
+
 	cookie, err := r.Cookie(auth.SessionCookieName)
 	if err == nil {
 		auth.Store.DeleteSession(cookie.Value)
@@ -107,15 +153,19 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		Title   string
-		User    interface{}
-		Success string
-		Error   string
+		Title      string
+		User       interface{}
+		Success    string
+		Error      string
+		RetryAfter string
+		CSRFToken  string
 	}{
-		Title:   "Mon profil",
-		User:    user,
-		Success: r.URL.Query().Get("success"),
-		Error:   r.URL.Query().Get("error"),
+		Title:      "Mon profil",
+		User:       user,
+		Success:    r.URL.Query().Get("success"),
+		Error:      r.URL.Query().Get("error"),
+		RetryAfter: formatRetryAfter(r.URL.Query().Get("retry")),
+		CSRFToken:  auth.CSRFToken(r),
 	}
 
 	templates.Templates.ExecuteTemplate(w, "profile.gohtml", data)
@@ -135,7 +185,13 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 
 	bio := r.FormValue("bio")
 
-	err := updateUserProfile(session.UserID, bio)
+	public := r.FormValue("public_profile") == "on"
+
+	err := updateUserProfile(session.UserID, bio, public)
+	if err == ErrEmailNotVerified {
+		http.Redirect(w, r, "/profile?error=unverified", http.StatusSeeOther)
+		return
+	}
 	if err != nil {
 		http.Redirect(w, r, "/profile?error=update", http.StatusSeeOther)
 		return
@@ -143,3 +199,22 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/profile?success=updated", http.StatusSeeOther)
 }
+
+// formatRetryAfter turns the retry delay of the login page, in seconds, into French text
+func formatRetryAfter(seconds string) string {
+	n, err := strconv.Atoi(seconds)
+	if err != nil || n <= 0 {
+		return ""
+	}
+	if n < 60 {
+		return plural(n, "seconde")
+	}
+	return plural((n+59)/60, "minute")
+}
+
+func plural(n int, unit string) string {
+	if n > 1 {
+		unit += "s"
+	}
+	return strconv.Itoa(n) + " " + unit
+}
@@ -2,6 +2,7 @@ package httphandlers
 
 import (
 	"errors"
+	"log"
 	"net/mail"
 	"strings"
 	"time"
@@ -25,23 +26,103 @@ var (
 	ErrBioTooLong       = errors.New("bio too long")
 )
 
-// authenticateUser authenticates a user and returns a sessionID
-func authenticateUser(username, password string) (string, error) {
+// LoginThrottleError rejects a login attempt made too soon after failures
+type LoginThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool // The account is locked, not merely slowed down
+}
+
+func (e *LoginThrottleError) Error() string {
+	if e.Locked {
+		return "account locked"
+	}
+	return "too many attempts"
+}
+
+// authenticateUser checks the password of a login attempt and returns the user.
+// Failures are throttled per IP and per username. Users with two-factor
+// authentication still have to pass verifySecondFactor.
+func authenticateUser(username, password string, client auth.Client) (*models.User, error) {
 	username = strings.TrimSpace(username)
+	userKey := auth.UsernameKey(username)
+
+	if err := checkLoginThrottle(userKey, client.IP); err != nil {
+		return nil, err
+	}
 
 	user, err := storage.GetUserByUsername(username)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, recordLoginFailure(nil, userKey, client.IP)
+	}
+
+	if err := checkAccountLock(user); err != nil {
+		return nil, err
 	}
 
 	if !auth.CheckPassword(user.Password, password) {
-		return "", ErrInvalidCredentials
+		return nil, recordLoginFailure(user, userKey, client.IP)
+	}
+
+	return user, nil
+}
+
+// completeLogin clears the failures of a user who passed every login step and returns a sessionID.
+// Failures are kept until then, so a known password does not reset the count of wrong codes.
+func completeLogin(user *models.User, client auth.Client) (string, error) {
+	auth.LoginUserLimiter.Reset(auth.UsernameKey(user.Username))
+	if user.FailedLogins > 0 || !user.LockedUntil.IsZero() {
+		if err := storage.ResetLoginFailures(user.ID); err != nil {
+			return "", ErrServerError
+		}
 	}
 
-	sessionID := auth.Store.CreateSession(user.ID, user.Username)
+	sessionID := auth.Store.CreateSession(user.ID, user.Username, client)
 	return sessionID, nil
 }
 
+// checkAccountLock rejects locked accounts. The lock is kept on the account so it survives restarts.
+func checkAccountLock(user *models.User) error {
+	if wait := time.Until(user.LockedUntil); wait > 0 {
+		return &LoginThrottleError{RetryAfter: wait, Locked: true}
+	}
+	return nil
+}
+
+// checkLoginThrottle rejects attempts while the username or the IP must wait
+func checkLoginThrottle(userKey, ip string) error {
+	if wait, locked := auth.LoginUserLimiter.Check(userKey); wait > 0 {
+		return &LoginThrottleError{RetryAfter: wait, Locked: locked}
+	}
+	if wait, _ := auth.LoginIPLimiter.Check(ip); wait > 0 {
+		return &LoginThrottleError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt and records it on the account, if any.
+// Only the failure fields are written, so a stale user cannot undo other changes to the account.
+// It returns the error shown to the user: invalid credentials unless the account just got locked.
+func recordLoginFailure(user *models.User, userKey, ip string) error {
+	auth.LoginIPLimiter.Failure(ip)
+	wait, locked := auth.LoginUserLimiter.Failure(userKey)
+
+	if user != nil {
+		now := time.Now()
+		var lockedUntil time.Time
+		if locked {
+			lockedUntil = now.Add(wait)
+		}
+		if err := storage.RecordLoginFailure(user.ID, now, lockedUntil); err != nil {
+			return ErrServerError
+		}
+	}
+
+	if locked {
+		return &LoginThrottleError{RetryAfter: wait, Locked: true}
+	}
+	return ErrInvalidCredentials
+}
+
 // registerNewUser creates a new user
 func registerNewUser(username, email, password string) error {
 	username = strings.TrimSpace(username)
@@ -65,11 +146,16 @@ func registerNewUser(username, email, password string) error {
 		CreatedAt: time.Now(),
 	}
 
-	_, err = storage.CreateUser(user)
+	created, err := storage.CreateUser(user)
 	if err != nil {
 		return ErrUserExists
 	}
 
+	// The account works right away; the link unlocks the gated features
+	if err := sendVerificationEmail(created); err != nil {
+		log.Println("Envoi de l'email de vérification impossible:", err)
+	}
+
 	return nil
 }
 
@@ -94,8 +180,9 @@ func validateRegistrationFields(username, email, password string) error {
 	return nil
 }
 
-// updateUserProfile updates a user's profile
-func updateUserProfile(userID int, bio string) error {
+// updateUserProfile updates a user's profile.
+// The profile can only be made public once the address is verified.
+func updateUserProfile(userID int, bio string, public bool) error {
 	user, err := storage.GetUserByID(userID)
 	if err != nil {
 		return ErrUserNotFound
@@ -105,9 +192,16 @@ func updateUserProfile(userID int, bio string) error {
 		return ErrBioTooLong
 	}
 
-	user.Bio = bio
+	if public && !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
 
-	if err := storage.UpdateUser(*user); err != nil {
+	err = storage.ModifyUser(userID, func(user *models.User) error {
+		user.Bio = bio
+		user.PublicProfile = public
+		return nil
+	})
+	if err != nil {
 		return ErrServerError
 	}
 
@@ -131,6 +225,20 @@ func getErrorCode(err error) string {
 		return "username"
 	case ErrBioTooLong:
 		return "bio"
+	case ErrWrongPassword:
+		return "wrong"
+	case ErrPasswordMismatch:
+		return "mismatch"
+	case ErrResetToken, ErrVerifyToken:
+		return "token"
+	case ErrEmailNotVerified:
+		return "unverified"
+	case ErrTooManyEmails:
+		return "throttled"
+	case ErrInvalidCode:
+		return "code"
+	case ErrTwoFactorState:
+		return "state"
 	default:
 		return "server"
 	}
@@ -12,6 +12,13 @@ import (
 )
 
 func ToggleFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	// State change: POST only, so the CSRF middleware checks it
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		return
+	}
+
 	// Auth via cookie session (current system)
 	session, ok := auth.GetUserFromRequest(r)
 	if !ok {
@@ -27,9 +34,14 @@ func ToggleFavoriteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Retrieve artist via API (existing function)
-	artist, err := util.FetchArtistByID(artistID)
+	// Retrieve artist from the cached catalog
+	catalog, err := util.Catalog.Get()
 	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+	artist, ok := catalog.ArtistByID(artistID)
+	if !ok {
 		http.Error(w, "Artiste introuvable", http.StatusNotFound)
 		return
 	}
@@ -0,0 +1,93 @@
+package httphandlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// ConcertsGeoJSONHandler serves the concerts of every artist matching the home filters
+// (GET /api/concerts.geojson)
+func ConcertsGeoJSONHandler(w http.ResponseWriter, r *http.Request) {
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	filters, errs := parseFilter(r)
+	if errs != nil {
+		sendFilterErrors(w, errs)
+		return
+	}
+
+	matches := filter.NewEngine(catalog, geo.Default).Match(filters)
+	sendGeoJSONResponse(w, matchFeatures(matches))
+}
+
+// ArtistAPIHandler routes the per-artist API (GET /api/artists/{id}/concerts.geojson)
+func ArtistAPIHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/artists/")
+	idPart, resource, found := strings.Cut(path, "/")
+	if !found || resource != "concerts.geojson" {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	artist, ok := catalog.ArtistByID(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	filters, errs := parseFilter(r)
+	if errs != nil {
+		sendFilterErrors(w, errs)
+		return
+	}
+
+	// An artist excluded by the filters has no concert to show
+	var matches []filter.Match
+	if match, ok := filter.NewEngine(catalog, geo.Default).MatchArtist(artist, filters); ok {
+		matches = append(matches, match)
+	}
+	sendGeoJSONResponse(w, matchFeatures(matches))
+}
+
+// matchFeatures places the concerts of the matching artists on the map
+func matchFeatures(matches []filter.Match) geo.FeatureCollection {
+	var concerts []util.Concert
+	names := make(map[int]string, len(matches))
+	for _, match := range matches {
+		concerts = append(concerts, match.Concerts...)
+		names[match.Artist.ID] = match.Artist.Name
+	}
+	return geo.Default.ConcertFeatures(concerts, names)
+}
+
+func sendGeoJSONResponse(w http.ResponseWriter, collection geo.FeatureCollection) {
+	w.Header().Set("Content-Type", "application/geo+json")
+
+	if err := json.NewEncoder(w).Encode(collection); err != nil {
+		http.Error(w, "Erreur encodage JSON", 500)
+		log.Println("GeoJSON error:", err)
+	}
+}
@@ -0,0 +1,27 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+)
+
+type Error403Data struct {
+	Title string
+	Path  string
+}
+
+// ForbiddenHandler answers requests rejected by the CSRF check
+func ForbiddenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+
+	data := Error403Data{
+		Title: "403 — Requête refusée",
+		Path:  r.URL.Path,
+	}
+
+	if err := templates.Templates.ExecuteTemplate(w, "error403.gohtml", data); err != nil {
+		http.Error(w, "403 — Requête refusée", http.StatusForbidden)
+	}
+}
@@ -2,9 +2,12 @@ package httphandlers
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
 
 	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
 	"github.com/YajiTV/groupie-tracker/internal/templates"
 	"github.com/YajiTV/groupie-tracker/internal/util"
 )
@@ -17,38 +20,81 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse filters from URL
-	filters := parseHomeFilters(r)
+	// Parse filters, sort and page from URL, invalid values are reported above the form
+	filters, filterErrors := parseFilter(r)
+	listing, listingErrors := filter.ParseListing(r.URL.Query())
+	filterErrors = append(filterErrors, listingErrors...)
 
-	// Retrieve all artists
-	allArtists, err := util.FetchArtists()
+	// Retrieve the cached catalog
+	catalog, err := util.Catalog.Get()
 	if err != nil {
 		http.Error(w, "Erreur lors de la récupération des artistes", http.StatusInternalServerError)
 		return
 	}
+	// Retrieve all available locations for the filter, as a continent → country → city tree
+	locationTree := groupLocationsByContinent(catalog.UniqueLocations())
 
-	// Retrieve relations (locations) for all artists
-	artistLocations := fetchArtistLocations()
+	// Apply filters
+	engine := filter.NewEngine(catalog, geo.Default)
+	matches := engine.Match(filters)
 
-	// Retrieve all available locations for the filter
-	allLocations := getAllUniqueLocationsFromRelations(artistLocations)
+	// Concert criteria: each artist shows its matching concerts, and its page keeps them
+	var matchedConcerts map[int][]util.Concert
+	var artistQuery template.URL
+	if filters.HasConcertCriteria() {
+		matchedConcerts = make(map[int][]util.Concert, len(matches))
+		for _, match := range matches {
+			matchedConcerts[match.Artist.ID] = match.Concerts
+		}
+		artistQuery = template.URL("?" + filters.ConcertCriteria().Encode().Encode())
+	}
 
-	// Apply filters
-	displayedArtists := applyHomeFilters(allArtists, filters, artistLocations)
+	// "Near me" search: nearest artists first, unless another sort is asked
+	var nearbyDistances map[int]float64
+	if filters.Origin != nil {
+		var sorted []filter.Match
+		sorted, nearbyDistances = sortMatchesByNearby(matches, findNearbyArtists(matches, *filters.Origin))
+		if listing.Sort == "" {
+			matches = sorted
+		}
+	}
+
+	// Sort and keep the requested page
+	pageMatches, pagination := listMatches(r, matches, listing)
 
 	// Prepare data for the template
 	data := struct {
 		Title           string
 		Artists         []util.Artist
-		Filters         HomeFilters
-		AllLocations    []string
+		Filters         filter.Filter
+		FilterErrors    filter.Errors
+		Facets          filter.Facets
+		Listing         filter.Listing
+		SortOptions     []filter.SortOption
+		Pagination      Pagination
+		LocationTree    []ContinentGroup
+		NearbyDistances map[int]float64
+		MatchedConcerts map[int][]util.Concert
+		ArtistQuery     template.URL // Concert criteria passed on to the artist pages
 		IsAuthenticated bool
+		EmailUnverified bool
+		CSRFToken       string
 	}{
 		Title:           "Groupie Tracker",
-		Artists:         displayedArtists,
+		Artists:         matchArtists(pageMatches),
 		Filters:         filters,
-		AllLocations:    allLocations,
+		FilterErrors:    filterErrors,
+		Facets:          engine.Facets(filters),
+		Listing:         listing,
+		SortOptions:     filter.SortOptions,
+		Pagination:      pagination,
+		LocationTree:    locationTree,
+		NearbyDistances: nearbyDistances,
+		MatchedConcerts: matchedConcerts,
+		ArtistQuery:     artistQuery,
 		IsAuthenticated: auth.IsAuthenticated(r),
+		EmailUnverified: emailUnverified(r),
+		CSRFToken:       auth.CSRFToken(r),
 	}
 
 	// Render the template
@@ -0,0 +1,99 @@
+package httphandlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// pageWindow is how many pages are linked around the current one
+const pageWindow = 2
+
+// PageLink is one entry of the pagination bar; a zero Number is a gap ("…")
+type PageLink struct {
+	Number  int
+	URL     string
+	Current bool
+}
+
+// Pagination is the page shown and the links to the others
+type Pagination struct {
+	filter.Page
+	PrevURL string
+	NextURL string
+	Links   []PageLink
+}
+
+// listMatches sorts the matches, then returns the requested page and its pagination links
+func listMatches(r *http.Request, matches []filter.Match, listing filter.Listing) ([]filter.Match, Pagination) {
+	var favoriteCounts map[int]int
+	if listing.Sort == filter.SortFavorites {
+		counts, err := storage.FavoriteCounts()
+		if err != nil {
+			log.Println("Favorite counts unavailable:", err)
+		}
+		favoriteCounts = counts
+	}
+	listing.SortMatches(matches, favoriteCounts)
+
+	page := listing.Paginate(len(matches))
+	return matches[page.Start:page.End], newPagination(r, page)
+}
+
+// newPagination builds the links of a page, keeping every other query parameter
+func newPagination(r *http.Request, page filter.Page) Pagination {
+	pagination := Pagination{Page: page}
+	if page.HasPrev() {
+		pagination.PrevURL = pageURL(r, page.Number-1)
+	}
+	if page.HasNext() {
+		pagination.NextURL = pageURL(r, page.Number+1)
+	}
+	if page.TotalPages == 1 {
+		return pagination
+	}
+
+	for n := 1; n <= page.TotalPages; n++ {
+		near := n >= page.Number-pageWindow && n <= page.Number+pageWindow
+		if n != 1 && n != page.TotalPages && !near {
+			// One gap per run of hidden pages
+			if last := len(pagination.Links) - 1; pagination.Links[last].Number != 0 {
+				pagination.Links = append(pagination.Links, PageLink{})
+			}
+			continue
+		}
+		pagination.Links = append(pagination.Links, PageLink{
+			Number:  n,
+			URL:     pageURL(r, n),
+			Current: n == page.Number,
+		})
+	}
+	return pagination
+}
+
+func pageURL(r *http.Request, number int) string {
+	query := r.URL.Query()
+	if number > 1 {
+		query.Set(filter.ParamPage, strconv.Itoa(number))
+	} else {
+		query.Del(filter.ParamPage)
+	}
+
+	if len(query) == 0 {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// matchArtists returns the artists of matches
+func matchArtists(matches []filter.Match) []util.Artist {
+	artists := make([]util.Artist, len(matches))
+	for i, match := range matches {
+		artists[i] = match.Artist
+	}
+	return artists
+}
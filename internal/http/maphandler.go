@@ -0,0 +1,80 @@
+package httphandlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// defaultMapZoom matches the initial view of the world map
+const defaultMapZoom = 2
+
+// MapHandler shows the concerts of every artist on a world map (GET /map)
+func MapHandler(w http.ResponseWriter, r *http.Request) {
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur lors de la récupération des artistes", http.StatusInternalServerError)
+		return
+	}
+
+	first, last := catalog.ConcertDateRange()
+	filters, filterErrors := parseFilter(r)
+
+	data := struct {
+		Title           string
+		Filters         filter.Filter
+		FilterErrors    filter.Errors
+		FirstDate       string
+		LastDate        string
+		IsAuthenticated bool
+	}{
+		Title:           "Carte des concerts",
+		Filters:         filters,
+		FilterErrors:    filterErrors,
+		FirstDate:       first.Format(geo.ISODateLayout),
+		LastDate:        last.Format(geo.ISODateLayout),
+		IsAuthenticated: auth.IsAuthenticated(r),
+	}
+
+	if err := templates.Templates.ExecuteTemplate(w, "map.gohtml", data); err != nil {
+		http.Error(w, fmt.Sprintf("Erreur lors du rendu du template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// MapConcertsHandler returns the clustered concerts shown on the world map
+// (GET /api/map/concerts?zoom=3&concert_from=2019-01-01&concert_to=2019-12-31 plus the other filter parameters)
+func MapConcertsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	zoom := defaultMapZoom
+	if val := query.Get("zoom"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 0 || parsed > geo.MaxZoom {
+			http.Error(w, "Zoom invalide", http.StatusBadRequest)
+			return
+		}
+		zoom = parsed
+	}
+
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	filters, errs := parseFilter(r)
+	if errs != nil {
+		sendFilterErrors(w, errs)
+		return
+	}
+
+	matches := filter.NewEngine(catalog, geo.Default).Match(filters)
+	sendGeoJSONResponse(w, geo.ClusterFeatures(matchFeatures(matches), zoom))
+}
@@ -0,0 +1,126 @@
+package httphandlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// NearbyConcert is a concert inside the search radius
+type NearbyConcert struct {
+	Slug       string  `json:"slug"`
+	Location   string  `json:"location"`
+	Date       string  `json:"date"` // ISO 8601
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// NearbyArtist is an artist playing inside the search radius, with the matching concerts
+type NearbyArtist struct {
+	ArtistID   int             `json:"artist_id"`
+	Name       string          `json:"name"`
+	DistanceKm float64         `json:"distance_km"` // Nearest concert
+	Concerts   []NearbyConcert `json:"concerts"`    // Sorted by distance then date
+
+	firstDate string // Date of the nearest concert, breaks distance ties
+}
+
+// NearbyResponse is the JSON returned by /api/nearby
+type NearbyResponse struct {
+	Origin   geo.Coord      `json:"origin"`
+	RadiusKm float64        `json:"radius_km"`
+	Results  []NearbyArtist `json:"results"`
+}
+
+// NearbyHandler lists the artists playing near a place or coordinates
+// (GET /api/nearby?near=Paris&radius_km=100, or near_lat/near_lon, plus the other filters)
+func NearbyHandler(w http.ResponseWriter, r *http.Request) {
+	filters, errs := parseFilter(r)
+	if errs == nil && filters.Origin == nil {
+		errs = filter.Errors{{Field: filter.ParamNear, Message: "Paramètre near ou near_lat/near_lon requis"}}
+	}
+	if errs != nil {
+		sendFilterErrors(w, errs)
+		return
+	}
+
+	catalog, err := util.Catalog.Get()
+	if err != nil {
+		http.Error(w, "Erreur API", http.StatusInternalServerError)
+		return
+	}
+
+	matches := filter.NewEngine(catalog, geo.Default).Match(filters)
+	sendJSONResponse(w, NearbyResponse{
+		Origin:   *filters.Origin,
+		RadiusKm: filters.RadiusKm,
+		Results:  findNearbyArtists(matches, *filters.Origin),
+	})
+}
+
+// findNearbyArtists measures the distance of each matching concert,
+// then orders artists nearest first, then by date of their nearest concert.
+// The matches must come from a filter with a "near me" center, so every concert is inside the radius.
+func findNearbyArtists(matches []filter.Match, origin geo.Coord) []NearbyArtist {
+	results := []NearbyArtist{}
+
+	for _, match := range matches {
+		var concerts []NearbyConcert
+		for _, concert := range match.Concerts {
+			result := geo.Default.Lookup(concert.Location)
+			if !result.Found || result.Approximate() {
+				continue
+			}
+			concerts = append(concerts, NearbyConcert{
+				Slug:       concert.Location.Slug,
+				Location:   concert.Location.String(),
+				Date:       concert.Date.Format(geo.ISODateLayout),
+				DistanceKm: math.Round(geo.DistanceKm(origin, result.Coord)*10) / 10,
+			})
+		}
+		if len(concerts) == 0 {
+			continue
+		}
+
+		sort.SliceStable(concerts, func(i, j int) bool {
+			if concerts[i].DistanceKm != concerts[j].DistanceKm {
+				return concerts[i].DistanceKm < concerts[j].DistanceKm
+			}
+			return concerts[i].Date < concerts[j].Date
+		})
+		results = append(results, NearbyArtist{
+			ArtistID:   match.Artist.ID,
+			Name:       match.Artist.Name,
+			DistanceKm: concerts[0].DistanceKm,
+			Concerts:   concerts,
+			firstDate:  concerts[0].Date,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].DistanceKm != results[j].DistanceKm {
+			return results[i].DistanceKm < results[j].DistanceKm
+		}
+		return results[i].firstDate < results[j].firstDate
+	})
+	return results
+}
+
+// sortMatchesByNearby orders matches like their nearby results and returns each artist's distance
+func sortMatchesByNearby(matches []filter.Match, nearby []NearbyArtist) ([]filter.Match, map[int]float64) {
+	byID := make(map[int]filter.Match, len(matches))
+	for _, match := range matches {
+		byID[match.Artist.ID] = match
+	}
+
+	sorted := make([]filter.Match, 0, len(nearby))
+	distances := make(map[int]float64, len(nearby))
+	for _, result := range nearby {
+		sorted = append(sorted, byID[result.ArtistID])
+		distances[result.ArtistID] = result.DistanceKm
+	}
+	return sorted, distances
+}
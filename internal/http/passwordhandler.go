@@ -0,0 +1,115 @@
+package httphandlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+)
+
+// ChangePasswordHandler changes the password from the profile (POST /profile/password).
+// The other sessions are logged out; this one stays.
+func ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	ip := auth.ClientFromRequest(r).IP
+	err := changePassword(session.UserID, r.FormValue("current_password"), r.FormValue("new_password"), r.FormValue("confirm_password"), ip)
+	if err != nil {
+		http.Redirect(w, r, profileErrorURL("password_", err), http.StatusSeeOther)
+		return
+	}
+
+	for _, other := range auth.Store.UserSessions(session.UserID) {
+		if other.Key != session.Key {
+			auth.Store.RevokeSession(session.UserID, other.Key)
+		}
+	}
+
+	http.Redirect(w, r, "/profile?success=password", http.StatusSeeOther)
+}
+
+// profileErrorURL sends a failed profile form back to the profile with its message.
+// Throttled attempts carry the wait, like on the login page.
+func profileErrorURL(prefix string, err error) string {
+	var throttled *LoginThrottleError
+	if errors.As(err, &throttled) {
+		retry := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		return "/profile?error=" + prefix + "throttled&retry=" + strconv.Itoa(retry)
+	}
+	return "/profile?error=" + prefix + getErrorCode(err)
+}
+
+// ForgotPasswordHandler shows the reset request form and emails the link (GET, POST /forgot)
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if err := requestPasswordReset(r.FormValue("email")); err != nil {
+			http.Redirect(w, r, "/forgot?error="+getErrorCode(err), http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/forgot?success=sent", http.StatusSeeOther)
+		return
+	}
+
+	data := struct {
+		Title     string
+		Error     string
+		Success   string
+		CSRFToken string
+	}{
+		Title:     "Mot de passe oublié",
+		Error:     r.URL.Query().Get("error"),
+		Success:   r.URL.Query().Get("success"),
+		CSRFToken: auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "forgot.gohtml", data)
+}
+
+// ResetPasswordHandler sets a new password from an emailed link (GET, POST /reset/{token})
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/reset/")
+
+	if r.Method == http.MethodPost {
+		err := resetPassword(token, r.FormValue("new_password"), r.FormValue("confirm_password"))
+		if err != nil {
+			http.Redirect(w, r, "/reset/"+url.PathEscape(token)+"?error="+getErrorCode(err), http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/login?success=reset", http.StatusSeeOther)
+		return
+	}
+
+	// Links are not cached or leaked to other sites through the Referer header
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	data := struct {
+		Title      string
+		Token      string
+		ValidToken bool
+		Error      string
+		CSRFToken  string
+	}{
+		Title:      "Nouveau mot de passe",
+		Token:      token,
+		ValidToken: validResetToken(token),
+		Error:      r.URL.Query().Get("error"),
+		CSRFToken:  auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "reset.gohtml", data)
+}
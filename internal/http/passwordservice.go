@@ -0,0 +1,198 @@
+package httphandlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/mail"
+	"github.com/YajiTV/groupie-tracker/internal/models"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+)
+
+// PasswordResetTTL is how long an emailed reset link stays valid
+const PasswordResetTTL = time.Hour
+
+// SiteURL prefixes the links sent by email. It never comes from the request Host header,
+// which an attacker could set to receive the reset tokens.
+var SiteURL = "http://localhost:8081"
+
+var (
+	ErrWrongPassword    = errors.New("wrong password")
+	ErrPasswordMismatch = errors.New("password mismatch")
+	ErrResetToken       = errors.New("invalid reset token") // Unknown, used or expired link
+	ErrTooManyEmails    = errors.New("too many emails")
+)
+
+// changePassword sets a new password after checking the current one
+func changePassword(userID int, current, password, confirm, ip string) error {
+	user, err := storage.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := confirmPassword(user, current, ip); err != nil {
+		return err
+	}
+
+	if err := validateNewPassword(password, confirm); err != nil {
+		return err
+	}
+
+	if err := setPassword(user, password); err != nil {
+		return err
+	}
+	notifyPasswordChanged(user)
+	return nil
+}
+
+// requestPasswordReset emails a reset link when an account uses this address.
+// Unknown addresses are not reported, so the form cannot tell who is registered.
+func requestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if !isValidEmail(email) {
+		return ErrInvalidEmail
+	}
+
+	// Silently drop requests flooding the same address
+	key := "reset:" + strings.ToLower(email)
+	if wait, _ := auth.MailLimiter.Check(key); wait > 0 {
+		return nil
+	}
+	auth.MailLimiter.Failure(key)
+
+	user, err := storage.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	token := auth.GenerateToken()
+	reset := storage.PasswordReset{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(PasswordResetTTL),
+	}
+	if err := storage.AddPasswordReset(reset); err != nil {
+		return ErrServerError
+	}
+
+	err = mail.Default.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Réinitialisation de votre mot de passe",
+		Body: "Bonjour " + user.Username + ",\n\n" +
+			"Pour choisir un nouveau mot de passe, ouvrez ce lien dans l'heure :\n" +
+			SiteURL + "/reset/" + token + "\n\n" +
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez ce message.\n",
+	})
+	if err != nil {
+		log.Println("Envoi de l'email de réinitialisation impossible:", err)
+		return ErrServerError
+	}
+	return nil
+}
+
+// validResetToken reports whether a reset link can still be used
+func validResetToken(token string) bool {
+	_, err := storage.FindPasswordReset(auth.HashToken(token))
+	return err == nil
+}
+
+// resetPassword sets a new password with an emailed token, which is then spent
+func resetPassword(token, password, confirm string) error {
+	if err := validateNewPassword(password, confirm); err != nil {
+		return err
+	}
+
+	reset, err := storage.TakePasswordReset(auth.HashToken(token))
+	if err != nil {
+		return ErrResetToken
+	}
+
+	user, err := storage.GetUserByID(reset.UserID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	// Whoever reset the password owns the account now: end the other logins and the lockout
+	if err := setPassword(user, password); err != nil {
+		return err
+	}
+	auth.LoginUserLimiter.Reset(auth.UsernameKey(user.Username))
+	if err := storage.ResetLoginFailures(user.ID); err != nil {
+		return ErrServerError
+	}
+	auth.Store.RevokeUserSessions(user.ID)
+	notifyPasswordChanged(user)
+	return nil
+}
+
+// confirmPassword checks the password of a signed-in user before a sensitive change.
+// Wrong guesses count like failed logins, so a stolen session cannot try passwords freely.
+func confirmPassword(user *models.User, password, ip string) error {
+	userKey := auth.UsernameKey(user.Username)
+	if err := checkLoginThrottle(userKey, ip); err != nil {
+		return err
+	}
+	if err := checkAccountLock(user); err != nil {
+		return err
+	}
+
+	if !auth.CheckPassword(user.Password, password) {
+		if err := recordLoginFailure(user, userKey, ip); err != ErrInvalidCredentials {
+			return err
+		}
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// validateNewPassword applies the registration rules to a new password
+func validateNewPassword(password, confirm string) error {
+	if password == "" {
+		return ErrEmptyFields
+	}
+	if len(password) < 6 {
+		return ErrPasswordTooShort
+	}
+	if password != confirm {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func setPassword(user *models.User, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return ErrServerError
+	}
+
+	err = storage.ModifyUser(user.ID, func(user *models.User) error {
+		user.Password = hash
+		return nil
+	})
+	if err != nil {
+		return ErrServerError
+	}
+	return nil
+}
+
+// notifyPasswordChanged warns the owner of the account, in case they did not do it.
+// Notifications only go to verified addresses.
+func notifyPasswordChanged(user *models.User) {
+	if !user.EmailVerified {
+		return
+	}
+
+	err := mail.Default.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Votre mot de passe a été modifié",
+		Body: "Bonjour " + user.Username + ",\n\n" +
+			"Le mot de passe de votre compte Groupie Tracker vient d'être modifié.\n" +
+			"Si vous n'êtes pas à l'origine de ce changement, réinitialisez-le sur " + SiteURL + "/forgot\n",
+	})
+	if err != nil {
+		log.Println("Envoi de la notification impossible:", err)
+	}
+}
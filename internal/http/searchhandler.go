@@ -3,17 +3,24 @@ package httphandlers
 import (
 	"log"
 	"net/http"
-	"strings"
 
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/search"
 	"github.com/YajiTV/groupie-tracker/internal/templates"
 	"github.com/YajiTV/groupie-tracker/internal/util"
 )
 
 type SearchData struct {
-	Title   string
-	Artists []util.Artist
-	Query   string
-	Count   int
+	Title        string
+	Artists      []util.Artist
+	Query        string
+	Count        int
+	FilterErrors filter.Errors
+	QueryErrors  search.Errors
+	Hits         map[int]util.ArtistMatch // Matched name or member of each artist, to highlight
+	FieldNames   []string
+	Pagination   Pagination
 }
 
 func SearchHandler(w http.ResponseWriter, r *http.Request) {
@@ -25,47 +32,44 @@ func SearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Retrieve all artists
-	allArtists, err := util.FetchArtists()
+	// Retrieve all artists from the cached catalog
+	catalog, err := util.Catalog.Get()
 	if err != nil {
 		http.Error(w, "Erreur API", 500)
 		log.Println("Error retrieving artists:", err)
 		return
 	}
 
-	// Filter artists
-	filteredArtists := searchArtists(allArtists, query)
+	// Filter artists, the other filter parameters narrow the search too
+	filters, filterErrors := parseFilter(r)
+	listing, listingErrors := filter.ParseListing(r.URL.Query())
+	filterErrors = append(filterErrors, listingErrors...)
 
-	data := SearchData{
-		Title:   "Résultats de recherche",
-		Artists: filteredArtists,
-		Query:   query,
-		Count:   len(filteredArtists),
-	}
-
-	templates.Templates.ExecuteTemplate(w, "search.gohtml", data)
-}
+	// The query uses the search language instead of the plain substring match of the home page.
+	// A query with syntax errors shows the errors and no result.
+	parsed, queryErrors := search.Parse(query)
+	filters.Query = ""
+	filters.Where = parsed.Predicate(catalog)
 
-// searchArtists filters artists by query (name or members)
-func searchArtists(artists []util.Artist, query string) []util.Artist {
-	var results []util.Artist
-	queryLower := strings.ToLower(query)
-
-	for _, artist := range artists {
-		// Search in artist name (case-insensitive)
-		if strings.Contains(strings.ToLower(artist.Name), queryLower) {
-			results = append(results, artist)
-			continue
-		}
+	var matches []filter.Match
+	if queryErrors == nil {
+		matches = filter.NewEngine(catalog, geo.Default).Match(filters)
+	}
+	// Most relevant first, unless another sort is asked
+	hits := parsed.Rank(catalog, matches)
+	pageMatches, pagination := listMatches(r, matches, listing)
 
-		// Search in members
-		for _, member := range artist.Members {
-			if strings.Contains(strings.ToLower(member), queryLower) {
-				results = append(results, artist)
-				break // Avoid duplicates
-			}
-		}
+	data := SearchData{
+		Title:        "Résultats de recherche",
+		Artists:      matchArtists(pageMatches),
+		Query:        query,
+		Count:        pagination.Total,
+		FilterErrors: filterErrors,
+		QueryErrors:  queryErrors,
+		Hits:         hits,
+		FieldNames:   search.FieldNames,
+		Pagination:   pagination,
 	}
 
-	return results
+	templates.Templates.ExecuteTemplate(w, "search.gohtml", data)
 }
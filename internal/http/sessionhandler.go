@@ -0,0 +1,83 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+)
+
+// SessionsHandler lists the active sessions of the user (GET /profile/sessions)
+func SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := struct {
+		Title      string
+		Sessions   []auth.SessionData
+		CurrentKey string
+		Success    string
+		Error      string
+		CSRFToken  string
+	}{
+		Title:      "Sessions actives",
+		Sessions:   auth.Store.UserSessions(session.UserID),
+		CurrentKey: session.Key,
+		Success:    r.URL.Query().Get("success"),
+		Error:      r.URL.Query().Get("error"),
+		CSRFToken:  auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "sessions.gohtml", data)
+}
+
+// RevokeSessionHandler logs out one session of the user (POST /profile/sessions/revoke)
+func RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile/sessions", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	key := r.FormValue("session")
+	if !auth.Store.RevokeSession(session.UserID, key) {
+		http.Redirect(w, r, "/profile/sessions?error=revoke", http.StatusSeeOther)
+		return
+	}
+
+	// Revoking the current session is a plain logout
+	if key == session.Key {
+		auth.ClearCookie(w)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/profile/sessions?success=revoked", http.StatusSeeOther)
+}
+
+// RevokeAllSessionsHandler logs the user out of every device, this one included
+// (POST /profile/sessions/revoke-all)
+func RevokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile/sessions", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	auth.Store.RevokeUserSessions(session.UserID)
+	auth.ClearCookie(w)
+	http.Redirect(w, r, "/login?success=signed_out", http.StatusSeeOther)
+}
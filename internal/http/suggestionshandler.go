@@ -2,23 +2,59 @@ package httphandlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf16"
 
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/index"
 	"github.com/YajiTV/groupie-tracker/internal/util"
 )
 
+// Suggestion types
+const (
+	SuggestionArtist   = "artiste"
+	SuggestionMember   = "membre"
+	SuggestionLocation = "lieu"
+	SuggestionAlbum    = "album"
+	SuggestionCreation = "création"
+)
+
+// suggestionLimits caps each type; the list shows the types in this order when their scores tie
+var suggestionLimits = []struct {
+	Type  string
+	Limit int
+}{
+	{SuggestionArtist, 5},
+	{SuggestionMember, 5},
+	{SuggestionLocation, 5},
+	{SuggestionAlbum, 3},
+	{SuggestionCreation, 3},
+}
+
 // Structure for ONE suggestion
 type Suggestion struct {
-	Text     string `json:"text"`      // The text to display: "Queen" or "Freddie Mercury"
-	Type     string `json:"type"`      // The type: "artist" or "member"
-	ArtistID int    `json:"artist_id"` // The artist ID to make the link
+	Text     string `json:"text"`                // The matched text: "Queen", "Freddie Mercury", "Osaka, Japan", "1973"
+	Type     string `json:"type"`                // One of the Suggestion* types
+	Label    string `json:"label"`               // Text with its context: "Freddie Mercury — membre de Queen"
+	URL      string `json:"url"`                 // Artist page, or home page filtered by the location or year
+	ArtistID int    `json:"artist_id,omitempty"` // Artist of artist, member, album and creation suggestions
+
+	// Matched part of Text, in JavaScript string indices, to highlight it
+	MatchStart int `json:"match_start"`
+	MatchEnd   int `json:"match_end"`
+
+	score int
 }
 
 // Structure for the complete response (what we return as JSON)
 type SuggestionsResponse struct {
-	Suggestions []Suggestion `json:"suggestions"`
+	Suggestions []Suggestion `json:"suggestions"` // Grouped by type, best group first
 }
 
 func SuggestionsHandler(w http.ResponseWriter, r *http.Request) {
@@ -31,60 +67,197 @@ func SuggestionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 2. Retrieve ALL artists from the API
-	allArtists, err := util.FetchArtists()
+	// 2. Retrieve the cached catalog
+	catalog, err := util.Catalog.Get()
 	if err != nil {
 		http.Error(w, "Erreur API", 500)
 		log.Println("Suggestions error:", err)
 		return
 	}
 
-	// 3. Search for matches
-	suggestions := findSuggestions(allArtists, query)
+	// 3. Search for matches, then keep the best of each type
+	suggestions := groupSuggestions(findSuggestions(catalog, query))
 
 	// 4. Return the JSON
 	sendJSONResponse(w, SuggestionsResponse{Suggestions: suggestions})
 }
 
-// Function that searches for matches in artists
-func findSuggestions(artists []util.Artist, query string) []Suggestion {
-	var suggestions []Suggestion
-	queryLower := strings.ToLower(query)
-	maxSuggestions := 8 // Limit to avoid too long list
+// Function that searches for matches of every type.
+// Artists are taken among those the index points to when it is ready.
+func findSuggestions(catalog *util.CatalogSnapshot, query string) []Suggestion {
+	var found []Suggestion
+	add := func(s Suggestion, match util.TextMatch, score int) {
+		if score <= 0 {
+			return
+		}
+		s.MatchStart = utf16Len(s.Text[:match.Span.Start])
+		s.MatchEnd = utf16Len(s.Text[:match.Span.End])
+		s.score = score
+		found = append(found, s)
+	}
 
-	for _, artist := range artists {
-		if len(suggestions) >= maxSuggestions {
-			break
+	textArtists, dateArtists := catalog.Artists, catalog.Artists
+	if ix := index.For(catalog); ix != nil {
+		textArtists = keepArtists(catalog.Artists, ix.Search(query, index.FieldText))
+		dateArtists = keepArtists(catalog.Artists, ix.Search(query, index.FieldAlbum|index.FieldCreation))
+	}
+
+	// SEARCH IN ARTIST NAMES AND MEMBERS
+	for _, artist := range textArtists {
+		artistURL := "/artist/" + strconv.Itoa(artist.ID)
+
+		match := util.MatchText(artist.Name, query)
+		add(Suggestion{Text: artist.Name, Type: SuggestionArtist, Label: artist.Name, URL: artistURL, ArtistID: artist.ID},
+			match, util.NameScore(match))
+
+		for _, member := range artist.Members {
+			match := util.MatchText(member, query)
+			add(Suggestion{
+				Text:     member,
+				Type:     SuggestionMember,
+				Label:    member + " — membre de " + artist.Name,
+				URL:      artistURL,
+				ArtistID: artist.ID,
+			}, match, util.MemberScore(match))
 		}
+	}
 
-		// SEARCH IN ARTIST NAME
-		if strings.Contains(strings.ToLower(artist.Name), queryLower) {
-			suggestions = append(suggestions, Suggestion{
-				Text:     artist.Name,
-				Type:     "artiste",
+	// SEARCH IN FIRST ALBUM AND CREATION DATES, without typo tolerance
+	for _, artist := range dateArtists {
+		if year, ok := filter.AlbumYear(artist); ok {
+			match := util.MatchText(artist.FirstAlbum, query)
+			add(Suggestion{
+				Text:     artist.FirstAlbum,
+				Type:     SuggestionAlbum,
+				Label:    artist.FirstAlbum + " — premier album de " + artist.Name,
+				URL:      yearFilterURL(filter.ParamAlbumYearMin, filter.ParamAlbumYearMax, year),
 				ArtistID: artist.ID,
-			})
+			}, match, exactScore(match))
 		}
 
-		// SEARCH IN MEMBERS (each member separately)
-		for _, member := range artist.Members {
-			if len(suggestions) >= maxSuggestions {
-				break
-			}
-
-			if strings.Contains(strings.ToLower(member), queryLower) {
-				suggestions = append(suggestions, Suggestion{
-					Text:     member,
-					Type:     "membre",
-					ArtistID: artist.ID,
-				})
-			}
+		year := strconv.Itoa(artist.CreationDate)
+		match := util.MatchText(year, query)
+		add(Suggestion{
+			Text:     year,
+			Type:     SuggestionCreation,
+			Label:    year + " — création de " + artist.Name,
+			URL:      yearFilterURL(filter.ParamCreationYearMin, filter.ParamCreationYearMax, artist.CreationDate),
+			ArtistID: artist.ID,
+		}, match, exactScore(match))
+	}
+
+	// SEARCH IN CONCERT LOCATIONS AND THEIR COUNTRIES
+	artistsAt := make(map[string]int)
+	artistsIn := make(map[string]int)
+	for _, locations := range catalog.ArtistLocations() {
+		countries := make(map[string]bool)
+		for _, location := range locations {
+			artistsAt[location.Slug]++
+			countries[location.Country] = true
 		}
+		for country := range countries {
+			artistsIn[country]++
+		}
+	}
+	for _, location := range catalog.UniqueLocations() {
+		text := location.String()
+		match := util.MatchText(text, query)
+		add(Suggestion{
+			Text:  text,
+			Type:  SuggestionLocation,
+			Label: text + " — " + countArtists(artistsAt[location.Slug]),
+			URL:   "/?" + url.Values{filter.ParamLocation: {location.Slug}}.Encode(),
+		}, match, util.NameScore(match))
+	}
+	for country, artists := range artistsIn {
+		match := util.MatchText(country, query)
+		add(Suggestion{
+			Text:  country,
+			Type:  SuggestionLocation,
+			Label: country + " — pays, " + countArtists(artists),
+			URL:   "/?" + url.Values{filter.ParamCountry: {country}}.Encode(),
+		}, match, util.NameScore(match))
 	}
 
+	return found
+}
+
+// groupSuggestions keeps the best suggestions of each type, up to its limit,
+// and lists the types by their best score
+func groupSuggestions(found []Suggestion) []Suggestion {
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+		return util.FoldText(found[i].Text) < util.FoldText(found[j].Text)
+	})
+
+	byType := make(map[string][]Suggestion)
+	for _, s := range found {
+		byType[s.Type] = append(byType[s.Type], s)
+	}
+
+	var groups [][]Suggestion
+	for _, t := range suggestionLimits {
+		group := byType[t.Type]
+		if len(group) == 0 {
+			continue
+		}
+		if len(group) > t.Limit {
+			group = group[:t.Limit]
+		}
+		groups = append(groups, group)
+	}
+	// Groups are sorted by score already, the first suggestion is the best of its group
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i][0].score > groups[j][0].score
+	})
+
+	suggestions := []Suggestion{}
+	for _, group := range groups {
+		suggestions = append(suggestions, group...)
+	}
 	return suggestions
 }
 
+// exactScore ranks a date match; typos make no sense in dates
+func exactScore(match util.TextMatch) int {
+	if match.Kind == util.MatchFuzzy {
+		return 0
+	}
+	return util.NameScore(match)
+}
+
+func yearFilterURL(minParam, maxParam string, year int) string {
+	return "/?" + url.Values{minParam: {strconv.Itoa(year)}, maxParam: {strconv.Itoa(year)}}.Encode()
+}
+
+func countArtists(n int) string {
+	if n == 1 {
+		return "1 artiste"
+	}
+	return fmt.Sprintf("%d artistes", n)
+}
+
+func keepArtists(artists []util.Artist, ids index.Set) []util.Artist {
+	var kept []util.Artist
+	for _, artist := range artists {
+		if ids.Has(artist.ID) {
+			kept = append(kept, artist)
+		}
+	}
+	return kept
+}
+
+// utf16Len counts the UTF-16 code units of s, the unit of JavaScript string indices
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
 // Utility function to return JSON cleanly
 func sendJSONResponse(w http.ResponseWriter, data interface{}) {
 	// Tell the browser we're returning JSON
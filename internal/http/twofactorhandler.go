@@ -0,0 +1,185 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+)
+
+// SecondFactorPageHandler asks for the code of a pending login (GET /login/2fa)
+func SecondFactorPageHandler(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := auth.ChallengeFromRequest(r); !ok {
+		http.Redirect(w, r, "/login?error=expired", http.StatusSeeOther)
+		return
+	}
+
+	data := struct {
+		Title     string
+		Error     string
+		CSRFToken string
+	}{
+		Title:     "Vérification en deux étapes",
+		Error:     r.URL.Query().Get("error"),
+		CSRFToken: auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "login2fa.gohtml", data)
+}
+
+// SecondFactorHandler checks the code of a pending login and opens the session (POST /auth/2fa)
+func SecondFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	token, challenge, ok := auth.ChallengeFromRequest(r)
+	if !ok {
+		auth.ClearChallengeCookie(w)
+		http.Redirect(w, r, "/login?error=expired", http.StatusSeeOther)
+		return
+	}
+
+	sessionID, err := verifySecondFactor(challenge, r.FormValue("code"))
+	if err == ErrInvalidCode {
+		http.Redirect(w, r, "/login/2fa?error=code", http.StatusSeeOther)
+		return
+	}
+
+	// Whatever happens next, the password has to be typed again
+	auth.Challenges.Delete(token)
+	auth.ClearChallengeCookie(w)
+	if err != nil {
+		redirectLoginError(w, r, err)
+		return
+	}
+
+	auth.SetCookie(w, sessionID)
+	http.Redirect(w, r, "/profile", http.StatusSeeOther)
+}
+
+// TwoFactorPageHandler shows the QR code of a pending enrollment (GET /profile/2fa)
+func TwoFactorPageHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	user, err := storage.GetUserByID(session.UserID)
+	if err != nil {
+		http.Error(w, "Utilisateur introuvable", http.StatusNotFound)
+		return
+	}
+
+	setup, err := twoFactorSetup(user)
+	if err != nil {
+		http.Redirect(w, r, "/profile?error=2fa_"+getErrorCode(err), http.StatusSeeOther)
+		return
+	}
+
+	// The secret is as sensitive as a password
+	w.Header().Set("Cache-Control", "no-store")
+
+	data := struct {
+		Title         string
+		Setup         TwoFactorSetup
+		RecoveryCodes []string
+		Error         string
+		CSRFToken     string
+	}{
+		Title:     "Double authentification",
+		Setup:     setup,
+		Error:     r.URL.Query().Get("error"),
+		CSRFToken: auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "twofactor.gohtml", data)
+}
+
+// StartTwoFactorHandler draws a new secret and shows it (POST /profile/2fa/setup)
+func StartTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := startTwoFactorSetup(session.UserID); err != nil {
+		http.Redirect(w, r, "/profile?error=2fa_"+getErrorCode(err), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/profile/2fa", http.StatusSeeOther)
+}
+
+// ConfirmTwoFactorHandler enables two-factor authentication and shows the recovery codes once
+// (POST /profile/2fa/confirm)
+func ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	codes, err := confirmTwoFactor(session.UserID, r.FormValue("code"))
+	if err == ErrInvalidCode {
+		http.Redirect(w, r, "/profile/2fa?error=code", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		http.Redirect(w, r, "/profile?error=2fa_"+getErrorCode(err), http.StatusSeeOther)
+		return
+	}
+
+	// Only hashes are kept, this page is the only place the codes appear
+	w.Header().Set("Cache-Control", "no-store")
+
+	data := struct {
+		Title         string
+		Setup         TwoFactorSetup
+		RecoveryCodes []string
+		Error         string
+		CSRFToken     string
+	}{
+		Title:         "Double authentification",
+		RecoveryCodes: codes,
+		CSRFToken:     auth.CSRFToken(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "twofactor.gohtml", data)
+}
+
+// DisableTwoFactorHandler turns two-factor authentication off (POST /profile/2fa/disable)
+func DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	ip := auth.ClientFromRequest(r).IP
+	if err := disableTwoFactor(session.UserID, r.FormValue("password"), r.FormValue("code"), ip); err != nil {
+		http.Redirect(w, r, profileErrorURL("2fa_", err), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/profile?success=2fa_disabled", http.StatusSeeOther)
+}
@@ -0,0 +1,209 @@
+package httphandlers
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/models"
+	"github.com/YajiTV/groupie-tracker/internal/qr"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+)
+
+// qrModuleSize is the size in pixels of a QR code module on the enrollment page
+const qrModuleSize = 5
+
+var (
+	ErrInvalidCode    = errors.New("invalid code")
+	ErrTwoFactorState = errors.New("two-factor authentication in another state")
+)
+
+// TwoFactorSetup is what the enrollment page shows to add the account to an app
+type TwoFactorSetup struct {
+	QRCode template.HTML // Inline SVG of the otpauth:// URI
+	Secret string        // Same secret in groups of four, to type by hand
+}
+
+// startTwoFactorSetup draws a new secret for a user who has not enrolled yet.
+// It is only used for login once confirmed with a code.
+func startTwoFactorSetup(userID int) error {
+	user, err := storage.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.TOTPEnabled {
+		return ErrTwoFactorState
+	}
+
+	err = storage.ModifyUser(userID, func(user *models.User) error {
+		user.TOTPSecret = auth.GenerateTOTPSecret()
+		user.TOTPLastCounter = 0
+		return nil
+	})
+	if err != nil {
+		return ErrServerError
+	}
+	return nil
+}
+
+// twoFactorSetup renders the pending secret of a user
+func twoFactorSetup(user *models.User) (TwoFactorSetup, error) {
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		return TwoFactorSetup{}, ErrTwoFactorState
+	}
+
+	code, err := qr.Encode(auth.TOTPURI(user.Username, user.TOTPSecret))
+	if err != nil {
+		return TwoFactorSetup{}, ErrServerError
+	}
+
+	var groups []string
+	for secret := user.TOTPSecret; secret != ""; {
+		n := min(4, len(secret))
+		groups = append(groups, secret[:n])
+		secret = secret[n:]
+	}
+
+	return TwoFactorSetup{
+		QRCode: template.HTML(code.SVG(qrModuleSize)),
+		Secret: strings.Join(groups, " "),
+	}, nil
+}
+
+// confirmTwoFactor enables two-factor authentication once the app shows the right code.
+// It returns the recovery codes, shown only this once.
+func confirmTwoFactor(userID int, code string) ([]string, error) {
+	user, err := storage.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		return nil, ErrTwoFactorState
+	}
+
+	counter, ok := auth.VerifyTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, ErrServerError
+	}
+	err = storage.ModifyUser(userID, func(stored *models.User) error {
+		// The secret may have been drawn again since the code was checked
+		if stored.TOTPEnabled || stored.TOTPSecret != user.TOTPSecret {
+			return ErrTwoFactorState
+		}
+		stored.TOTPEnabled = true
+		stored.TOTPLastCounter = counter
+		stored.RecoveryCodes = hashes
+		return nil
+	})
+	switch err {
+	case nil:
+		return codes, nil
+	case ErrTwoFactorState:
+		return nil, err
+	default:
+		return nil, ErrServerError
+	}
+}
+
+// disableTwoFactor turns two-factor authentication off. It takes both the password and a code,
+// so neither a stolen session nor a leaked password is enough. Wrong guesses count like
+// failed logins, so the session cannot try them freely either.
+func disableTwoFactor(userID int, password, code, ip string) error {
+	user, err := storage.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorState
+	}
+
+	if err := confirmPassword(user, password, ip); err != nil {
+		return err
+	}
+
+	err = storage.ModifyUser(userID, func(user *models.User) error {
+		if !user.TOTPEnabled {
+			return ErrTwoFactorState
+		}
+		if !useSecondFactor(user, code) {
+			return ErrInvalidCode
+		}
+		user.TOTPEnabled = false
+		user.TOTPSecret = ""
+		user.TOTPLastCounter = 0
+		user.RecoveryCodes = nil
+		return nil
+	})
+	switch err {
+	case nil:
+		return nil
+	case ErrInvalidCode:
+		if err := recordLoginFailure(user, auth.UsernameKey(user.Username), ip); err != ErrInvalidCredentials {
+			return err
+		}
+		return ErrInvalidCode
+	case ErrTwoFactorState:
+		return err
+	default:
+		return ErrServerError
+	}
+}
+
+// verifySecondFactor checks the code of a pending login and returns a sessionID.
+// Wrong codes count as login failures, so they lead to the same lockout as wrong passwords.
+func verifySecondFactor(challenge auth.LoginChallenge, code string) (string, error) {
+	user, err := storage.GetUserByID(challenge.UserID)
+	if err != nil || !user.TOTPEnabled {
+		return "", ErrInvalidCredentials
+	}
+
+	userKey := auth.UsernameKey(user.Username)
+	if err := checkLoginThrottle(userKey, challenge.Client.IP); err != nil {
+		return "", err
+	}
+	if err := checkAccountLock(user); err != nil {
+		return "", err
+	}
+
+	// The code is checked and spent under the storage lock, so it works only once
+	// and nothing else on the account is overwritten
+	err = storage.ModifyUser(user.ID, func(user *models.User) error {
+		if !user.TOTPEnabled || !useSecondFactor(user, code) {
+			return ErrInvalidCode
+		}
+		return nil
+	})
+	switch err {
+	case nil:
+		return completeLogin(user, challenge.Client)
+	case ErrInvalidCode:
+		if err := recordLoginFailure(user, userKey, challenge.Client.IP); err != ErrInvalidCredentials {
+			return "", err
+		}
+		return "", ErrInvalidCode
+	default:
+		return "", ErrServerError
+	}
+}
+
+// useSecondFactor checks an app code or a recovery code and spends it on user.
+// Callers run it inside storage.ModifyUser, which saves the user.
+func useSecondFactor(user *models.User, code string) bool {
+	if counter, ok := auth.VerifyTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter); ok {
+		user.TOTPLastCounter = counter
+		return true
+	}
+
+	left, ok := auth.UseRecoveryCode(user.RecoveryCodes, code)
+	if ok {
+		user.RecoveryCodes = left
+	}
+	return ok
+}
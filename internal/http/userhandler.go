@@ -0,0 +1,42 @@
+package httphandlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+	"github.com/YajiTV/groupie-tracker/internal/templates"
+)
+
+// PublicProfileHandler shows the public profile of a user (GET /user/{username}).
+// Profiles stay hidden until their owner makes them public and verifies their address.
+func PublicProfileHandler(w http.ResponseWriter, r *http.Request) {
+	username := strings.TrimPrefix(r.URL.Path, "/user/")
+
+	user, err := storage.GetUserByUsername(username)
+	if err != nil || !user.PublicProfile || !user.EmailVerified {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	favorites, err := storage.GetFavorites(user.ID)
+	if err != nil {
+		http.Error(w, "Erreur lors de la récupération des favoris", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title           string
+		User            interface{}
+		Favorites       []storage.Favorite
+		IsAuthenticated bool
+	}{
+		Title:           user.Username,
+		User:            user,
+		Favorites:       favorites,
+		IsAuthenticated: auth.IsAuthenticated(r),
+	}
+
+	templates.Templates.ExecuteTemplate(w, "user.gohtml", data)
+}
@@ -0,0 +1,41 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+)
+
+// VerifyEmailHandler confirms an address from an emailed link (GET /verify?token=...)
+func VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	next := "/login"
+	if auth.IsAuthenticated(r) {
+		next = "/profile"
+	}
+
+	if err := verifyEmail(r.URL.Query().Get("token")); err != nil {
+		http.Redirect(w, r, next+"?error=verify_"+getErrorCode(err), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, next+"?success=verified", http.StatusSeeOther)
+}
+
+// ResendVerificationHandler emails a new verification link (POST /verify/resend)
+func ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := resendVerification(session.UserID); err != nil {
+		http.Redirect(w, r, "/profile?error=verify_"+getErrorCode(err), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/profile?success=verification_sent", http.StatusSeeOther)
+}
@@ -0,0 +1,110 @@
+package httphandlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+	"github.com/YajiTV/groupie-tracker/internal/mail"
+	"github.com/YajiTV/groupie-tracker/internal/models"
+	"github.com/YajiTV/groupie-tracker/internal/storage"
+)
+
+// EmailVerificationTTL is how long an emailed verification link stays valid
+const EmailVerificationTTL = 7 * 24 * time.Hour
+
+// verifyTokenPrefix keeps verification tokens from being accepted for another purpose
+const verifyTokenPrefix = "verify:"
+
+var (
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrVerifyToken      = errors.New("invalid verification token") // Forged, expired or for an old address
+)
+
+// sendVerificationEmail emails a signed link confirming the address of user.
+// The link names the address, so it stops working if the address changes.
+func sendVerificationEmail(user *models.User) error {
+	payload := verifyTokenPrefix + strconv.Itoa(user.ID) + ":" + strings.ToLower(user.Email)
+	token := auth.SignToken(payload, time.Now().Add(EmailVerificationTTL))
+
+	return mail.Default.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirmez votre adresse email",
+		Body: "Bonjour " + user.Username + ",\n\n" +
+			"Pour confirmer votre adresse et activer toutes les fonctionnalités de votre compte, ouvrez ce lien :\n" +
+			SiteURL + "/verify?token=" + url.QueryEscape(token) + "\n\n" +
+			"Le lien est valable 7 jours. Si vous n'avez pas créé de compte, ignorez ce message.\n",
+	})
+}
+
+// resendVerification sends a new verification link, at a throttled pace
+func resendVerification(userID int) error {
+	user, err := storage.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	key := "verify:" + strconv.Itoa(user.ID)
+	if wait, _ := auth.MailLimiter.Check(key); wait > 0 {
+		return ErrTooManyEmails
+	}
+	auth.MailLimiter.Failure(key)
+
+	if err := sendVerificationEmail(user); err != nil {
+		log.Println("Envoi de l'email de vérification impossible:", err)
+		return ErrServerError
+	}
+	return nil
+}
+
+// verifyEmail marks the address named by a verification link as verified
+func verifyEmail(token string) error {
+	payload, err := auth.VerifyToken(token)
+	if err != nil || !strings.HasPrefix(payload, verifyTokenPrefix) {
+		return ErrVerifyToken
+	}
+
+	idStr, email, ok := strings.Cut(strings.TrimPrefix(payload, verifyTokenPrefix), ":")
+	if !ok {
+		return ErrVerifyToken
+	}
+	userID, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ErrVerifyToken
+	}
+
+	user, err := storage.GetUserByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, email) {
+		return ErrVerifyToken
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	err = storage.ModifyUser(userID, func(user *models.User) error {
+		user.EmailVerified = true
+		return nil
+	})
+	if err != nil {
+		return ErrServerError
+	}
+	return nil
+}
+
+// emailUnverified reports whether the logged-in user still has to confirm their address
+func emailUnverified(r *http.Request) bool {
+	session, ok := auth.GetUserFromRequest(r)
+	if !ok {
+		return false
+	}
+	user, err := storage.GetUserByID(session.UserID)
+	return err == nil && !user.EmailVerified
+}
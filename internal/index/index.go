@@ -0,0 +1,239 @@
+// Package index is an inverted index of the artist catalog: every folded word of the names,
+// members, concert places and dates points to the artists carrying it.
+// It is rebuilt with each catalog snapshot and only narrows the candidates;
+// callers still check them with the exact matching rules.
+package index
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Field tells where a term was found. Fields combine as a bit mask.
+type Field uint16
+
+const (
+	FieldName      Field = 1 << iota
+	FieldMember          // Member names
+	FieldCity            // City, region or "City, Country" of a concert
+	FieldCountry         // Country of a concert
+	FieldContinent       // Continent of a concert
+	FieldSlug            // Location slug of a concert
+	FieldCreation        // Creation year
+	FieldAlbum           // First album year, month and date
+	FieldConcert         // Concert years, months and dates
+
+	FieldText  = FieldName | FieldMember
+	FieldPlace = FieldCity | FieldCountry | FieldContinent | FieldSlug
+	FieldDate  = FieldCreation | FieldAlbum | FieldConcert
+	FieldAll   = FieldText | FieldPlace | FieldDate
+)
+
+// Set is a set of artist IDs
+type Set map[int]bool
+
+// Has reports whether id is in the set
+func (s Set) Has(id int) bool {
+	return s[id]
+}
+
+// Intersect keeps the IDs present in both sets; a nil set stands for every artist
+func Intersect(a, b Set) Set {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	out := make(Set)
+	for id := range a {
+		if b[id] {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+type posting struct {
+	artistID int
+	fields   Field
+}
+
+// Index maps folded terms to the artists carrying them
+type Index struct {
+	snapshot *util.CatalogSnapshot
+	terms    []string             // Sorted vocabulary
+	lettered []bool               // Whether each term has a letter, only those are compared with typos
+	postings map[string][]posting // Sorted by artist ID
+}
+
+// Build indexes a catalog snapshot
+func Build(snapshot *util.CatalogSnapshot) *Index {
+	fields := make(map[string]map[int]Field)
+	add := func(artistID int, field Field, term string) {
+		if term == "" {
+			return
+		}
+		if fields[term] == nil {
+			fields[term] = make(map[int]Field)
+		}
+		fields[term][artistID] |= field
+	}
+	// Text values are indexed whole and word by word
+	addText := func(artistID int, field Field, value string) {
+		folded := strings.TrimSpace(util.FoldText(value))
+		add(artistID, field, folded)
+		for _, word := range words(folded) {
+			add(artistID, field, word)
+		}
+	}
+	addDate := func(artistID int, field Field, date time.Time) {
+		add(artistID, field, date.Format("2006"))
+		add(artistID, field, date.Format("2006-01"))
+		add(artistID, field, date.Format("2006-01-02"))
+		add(artistID, field, date.Format(util.ConcertDateLayout))
+	}
+
+	for _, artist := range snapshot.Artists {
+		id := artist.ID
+		addText(id, FieldName, artist.Name)
+		for _, member := range artist.Members {
+			addText(id, FieldMember, member)
+		}
+		add(id, FieldCreation, strconv.Itoa(artist.CreationDate))
+		if album, err := time.Parse(util.ConcertDateLayout, strings.TrimSpace(artist.FirstAlbum)); err == nil {
+			addDate(id, FieldAlbum, album)
+		}
+
+		for _, concert := range snapshot.ArtistConcerts(id) {
+			location := concert.Location
+			add(id, FieldSlug, location.Slug)
+			addText(id, FieldCity, location.City)
+			addText(id, FieldCity, location.Region)
+			addText(id, FieldCity, location.String())
+			addText(id, FieldCountry, location.Country)
+			addText(id, FieldContinent, location.Continent)
+			addDate(id, FieldConcert, concert.Date)
+		}
+	}
+
+	ix := &Index{
+		snapshot: snapshot,
+		terms:    make([]string, 0, len(fields)),
+		postings: make(map[string][]posting, len(fields)),
+	}
+	for term, artists := range fields {
+		ix.terms = append(ix.terms, term)
+		list := make([]posting, 0, len(artists))
+		for id, field := range artists {
+			list = append(list, posting{artistID: id, fields: field})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].artistID < list[j].artistID })
+		ix.postings[term] = list
+	}
+	sort.Strings(ix.terms)
+	ix.lettered = make([]bool, len(ix.terms))
+	for i, term := range ix.terms {
+		ix.lettered[i] = hasLetter(term)
+	}
+	return ix
+}
+
+// Len returns the number of distinct terms
+func (ix *Index) Len() int {
+	return len(ix.terms)
+}
+
+// Lookup returns the artists carrying exactly term (folded) in one of fields
+func (ix *Index) Lookup(term string, fields Field) Set {
+	set := make(Set)
+	ix.collect(set, strings.TrimSpace(util.FoldText(term)), fields)
+	return set
+}
+
+// Prefix returns the artists carrying a term starting with prefix (folded) in one of fields
+func (ix *Index) Prefix(prefix string, fields Field) Set {
+	prefix = strings.TrimSpace(util.FoldText(prefix))
+	set := make(Set)
+	for i := sort.SearchStrings(ix.terms, prefix); i < len(ix.terms) && strings.HasPrefix(ix.terms[i], prefix); i++ {
+		ix.collect(set, ix.terms[i], fields)
+	}
+	return set
+}
+
+// Search returns the artists that may match a free text query in one of fields:
+// every word of the query must appear inside one of their terms, or be within
+// util.MaxTypos of a term or of its start. Dates and numbers are never fuzzy.
+func (ix *Index) Search(query string, fields Field) Set {
+	folded := strings.TrimSpace(util.FoldText(query))
+	maxTypos := util.MaxTypos(folded)
+
+	var result Set
+	for _, word := range strings.Fields(folded) {
+		set := make(Set)
+		fuzzy := maxTypos > 0 && hasLetter(word)
+		for i, term := range ix.terms {
+			if strings.Contains(term, word) || (fuzzy && ix.lettered[i] && withinTypos(term, word, maxTypos)) {
+				ix.collect(set, term, fields)
+			}
+		}
+		result = Intersect(result, set)
+		if len(result) == 0 {
+			break
+		}
+	}
+	if result == nil {
+		result = make(Set)
+	}
+	return result
+}
+
+func (ix *Index) collect(set Set, term string, fields Field) {
+	for _, p := range ix.postings[term] {
+		if p.fields&fields != 0 {
+			set[p.artistID] = true
+		}
+	}
+}
+
+// withinTypos compares word with term and with the start of term (a word being typed)
+func withinTypos(term, word string, maxTypos int) bool {
+	t, w := []rune(term), []rune(word)
+	if d := len(t) - len(w); d >= -maxTypos && d <= maxTypos && util.EditDistance(term, word) <= maxTypos {
+		return true
+	}
+	return len(t) > len(w) && util.EditDistance(string(t[:len(w)]), word) <= maxTypos
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hasLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+var current atomic.Pointer[Index]
+
+// Publish makes ix the index returned by For, replacing the previous one at once
+func Publish(ix *Index) {
+	current.Store(ix)
+}
+
+// For returns the index built from snapshot, or nil while it is not ready
+// (callers then scan the catalog)
+func For(snapshot *util.CatalogSnapshot) *Index {
+	ix := current.Load()
+	if ix == nil || ix.snapshot != snapshot {
+		return nil
+	}
+	return ix
+}
@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes each message to an .eml file for local development
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes the message to Dir and logs where it is
+func (m FileMailer) Send(msg Message) error {
+	if !validAddress(msg.To) {
+		return errors.New("adresse email invalide")
+	}
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	file, err := os.CreateTemp(m.Dir, now.Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(msg.format(m.From, now)); err != nil {
+		return err
+	}
+	log.Printf("Email pour %s écrit dans %s\n", msg.To, filepath.ToSlash(file.Name()))
+	return nil
+}
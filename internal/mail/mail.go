@@ -0,0 +1,59 @@
+// Package mail sends the emails of the site: password resets, notifications.
+// Messages go through a Mailer, SMTP in production and files on disk in development.
+package mail
+
+import (
+	"log"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by the handlers, replaced at startup by the configured one
+var Default Mailer = LogMailer{}
+
+// LogMailer only logs messages, links included. Useful when nothing else is configured.
+type LogMailer struct{}
+
+// Send logs the message
+func (LogMailer) Send(msg Message) error {
+	log.Printf("Email pour %s: %s\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// format renders the message with its headers, CRLF line endings as required by RFC 5322
+func (msg Message) format(from string, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + encodeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// encodeHeader encodes non-ASCII header values (RFC 2047)
+func encodeHeader(value string) string {
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// validAddress rejects addresses that would inject headers
+func validAddress(address string) bool {
+	return address != "" && !strings.ContainsAny(address, "\r\n")
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer sends messages through an SMTP server.
+// Authentication is used when Username is set; it requires TLS unless the server is local.
+type SMTPMailer struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+// Send delivers the message
+func (m SMTPMailer) Send(msg Message) error {
+	if !validAddress(msg.To) || !validAddress(m.From) {
+		return errors.New("adresse email invalide")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, msg.format(m.From, time.Now()))
+}
@@ -11,6 +11,21 @@ type User struct {
 	AvatarURL string    `json:"avatar_url"`
 	Bio       string    `json:"bio"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Features like the public profile and notification emails wait for a verified address
+	EmailVerified bool `json:"email_verified"`
+	PublicProfile bool `json:"public_profile"`
+
+	// Two-factor authentication. TOTPSecret is set during enrollment, before TOTPEnabled.
+	TOTPSecret      string   `json:"totp_secret,omitempty"`
+	TOTPEnabled     bool     `json:"totp_enabled"`
+	TOTPLastCounter int64    `json:"totp_last_counter,omitempty"` // Last accepted time step: a code works once
+	RecoveryCodes   []string `json:"recovery_codes,omitempty"`    // Bcrypt hashes of the unused recovery codes
+
+	// Login failures since the last successful login
+	FailedLogins    int       `json:"failed_logins"`
+	LastFailedLogin time.Time `json:"last_failed_login"`
+	LockedUntil     time.Time `json:"locked_until"`
 }
 
 // UserData contains all users (for JSON)
@@ -0,0 +1,247 @@
+// Package qr encodes text as a QR code (ISO/IEC 18004) and renders it as SVG.
+// It supports byte mode at error correction level M for versions 1 to 10,
+// which is plenty for otpauth:// URIs.
+package qr
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Code is an encoded QR code. Modules are indexed [row][column], true is dark.
+type Code struct {
+	Size    int
+	Modules [][]bool
+}
+
+// ErrTooLong is returned when the text does not fit in a version 10 code
+var ErrTooLong = errors.New("qr: text too long")
+
+// blockLayout describes the error correction blocks of a version at level M
+type blockLayout struct {
+	ecPerBlock int
+	groups     [][2]int // {number of blocks, data codewords per block}
+}
+
+var layouts = [...]blockLayout{
+	1:  {10, [][2]int{{1, 16}}},
+	2:  {16, [][2]int{{1, 28}}},
+	3:  {26, [][2]int{{1, 44}}},
+	4:  {18, [][2]int{{2, 32}}},
+	5:  {24, [][2]int{{2, 43}}},
+	6:  {16, [][2]int{{4, 27}}},
+	7:  {18, [][2]int{{4, 31}}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// alignmentPositions lists the centers of the alignment patterns on each axis
+var alignmentPositions = [...][]int{
+	1:  nil,
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+const maxVersion = 10
+
+func (l blockLayout) dataCodewords() int {
+	total := 0
+	for _, group := range l.groups {
+		total += group[0] * group[1]
+	}
+	return total
+}
+
+// Encode returns the smallest QR code holding text
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= layouts[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version), layouts[version])
+
+	m := newMatrix(version)
+	m.drawFunctionPatterns()
+	m.drawCodewords(codewords)
+
+	// Keep the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask) // XOR again to undo
+	}
+	m.applyMask(best)
+	m.drawFormatBits(best)
+
+	return &Code{Size: m.size, Modules: m.modules}, nil
+}
+
+// countBits is the length of the byte mode character count
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData builds the data codewords: mode, count, bytes, terminator and padding
+func encodeData(data []byte, version int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4) // Byte mode
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := layouts[version].dataCodewords() * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// addErrorCorrection splits data into blocks, computes their Reed-Solomon
+// codewords and interleaves everything
+func addErrorCorrection(data []byte, layout blockLayout) []byte {
+	divisor := reedSolomonDivisor(layout.ecPerBlock)
+
+	var blocks, ecBlocks [][]byte
+	for _, group := range layout.groups {
+		for i := 0; i < group[0]; i++ {
+			block := data[:group[1]]
+			data = data[group[1]:]
+			blocks = append(blocks, block)
+			ecBlocks = append(ecBlocks, reedSolomonRemainder(block, divisor))
+		}
+	}
+
+	var out []byte
+	for i := 0; ; i++ {
+		added := false
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for i := 0; i < layout.ecPerBlock; i++ {
+		for _, ec := range ecBlocks {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree,
+// highest coefficient first and the leading 1 omitted
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// SVG renders the code with square modules of moduleSize pixels and the
+// standard four-module quiet zone
+func (c *Code) SVG(moduleSize int) string {
+	const quiet = 4
+	full := (c.Size + 2*quiet) * moduleSize
+	size := strconv.Itoa(full)
+
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="` + size + `" height="` + size +
+		`" viewBox="0 0 ` + strconv.Itoa(c.Size+2*quiet) + ` ` + strconv.Itoa(c.Size+2*quiet) +
+		`" shape-rendering="crispEdges" role="img" aria-label="QR code">`)
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range c.Modules {
+		for x, dark := range row {
+			if dark {
+				b.WriteString("M" + strconv.Itoa(x+quiet) + "," + strconv.Itoa(y+quiet) + "h1v1h-1z")
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
@@ -0,0 +1,423 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The tests read codes back with a decoder written from the tables of ISO/IEC 18004,
+// so they do not share the encoder's mistakes.
+
+// specBlocks is the level M error correction table: {ec codewords per block, {blocks, data codewords}...}
+var specBlocks = map[int]struct {
+	ec     int
+	groups [][2]int
+}{
+	1:  {10, [][2]int{{1, 16}}},
+	2:  {16, [][2]int{{1, 28}}},
+	3:  {26, [][2]int{{1, 44}}},
+	4:  {18, [][2]int{{2, 32}}},
+	5:  {24, [][2]int{{2, 43}}},
+	6:  {16, [][2]int{{4, 27}}},
+	7:  {18, [][2]int{{4, 31}}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// specTotalCodewords is the number of codewords of each version, all levels alike
+var specTotalCodewords = map[int]int{1: 26, 2: 44, 3: 70, 4: 100, 5: 134, 6: 172, 7: 196, 8: 242, 9: 292, 10: 346}
+
+// specAlignment lists the alignment pattern centers of each version
+var specAlignment = map[int][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// specFormatM is the masked format information of level M, indexed by mask
+var specFormatM = [8]string{
+	"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+	"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+}
+
+// specVersionInfo is the version information of versions 7 and up
+var specVersionInfo = map[int]string{
+	7:  "000111110010010100",
+	8:  "001000010110111100",
+	9:  "001001101010011001",
+	10: "001010010011010011",
+}
+
+// specMasks are the data mask conditions, for row i and column j
+var specMasks = [8]func(i, j int) bool{
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return i%2 == 0 },
+	func(i, j int) bool { return j%3 == 0 },
+	func(i, j int) bool { return (i+j)%3 == 0 },
+	func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+	func(i, j int) bool { return (i*j)%2+(i*j)%3 == 0 },
+	func(i, j int) bool { return ((i*j)%2+(i*j)%3)%2 == 0 },
+	func(i, j int) bool { return ((i+j)%2+(i*j)%3)%2 == 0 },
+}
+
+// decode reads the text of a byte mode, level M code, failing the test on any defect
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	size := len(c.Modules)
+	version := (size - 17) / 4
+	if c.Size != size || (size-17)%4 != 0 || version < 1 || version > 10 {
+		t.Fatalf("unexpected size %d (%d rows)", c.Size, size)
+	}
+	for _, row := range c.Modules {
+		if len(row) != size {
+			t.Fatalf("row of %d modules in a code of size %d", len(row), size)
+		}
+	}
+	dark := func(row, col int) bool { return c.Modules[row][col] }
+	read := func(cells [][2]int) string {
+		var b strings.Builder
+		for _, cell := range cells {
+			if dark(cell[0], cell[1]) {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+		return b.String()
+	}
+
+	// Finder patterns, timing patterns and the dark module
+	for _, corner := range [][2]int{{0, 0}, {0, size - 7}, {size - 7, 0}} {
+		for i := 0; i < 7; i++ {
+			for j := 0; j < 7; j++ {
+				ring := max(abs(i-3), abs(j-3))
+				if dark(corner[0]+i, corner[1]+j) != (ring != 2) {
+					t.Fatalf("finder at %v is wrong at (%d, %d)", corner, i, j)
+				}
+			}
+		}
+	}
+	for k := 8; k < size-8; k++ {
+		if dark(6, k) != (k%2 == 0) || dark(k, 6) != (k%2 == 0) {
+			t.Fatalf("timing pattern is wrong at %d", k)
+		}
+	}
+	if !dark(size-8, 8) {
+		t.Fatal("dark module is light")
+	}
+
+	// Format information, most significant bit first, in both copies
+	var first, second [][2]int
+	for col := 0; col <= 8; col++ {
+		if col != 6 {
+			first = append(first, [2]int{8, col})
+		}
+	}
+	for row := 7; row >= 0; row-- {
+		if row != 6 {
+			first = append(first, [2]int{row, 8})
+		}
+	}
+	for row := size - 1; row >= size-7; row-- {
+		second = append(second, [2]int{row, 8})
+	}
+	for col := size - 8; col < size; col++ {
+		second = append(second, [2]int{8, col})
+	}
+	format := read(first)
+	if other := read(second); other != format {
+		t.Fatalf("format copies differ: %s and %s", format, other)
+	}
+	mask := -1
+	for m, bits := range specFormatM {
+		if bits == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format %s is not level M", format)
+	}
+
+	// Version information: a 6x3 block by each of the upper right and lower left finders
+	if version >= 7 {
+		var upper, lower [][2]int
+		for k := 17; k >= 0; k-- {
+			upper = append(upper, [2]int{k / 3, size - 11 + k%3})
+			lower = append(lower, [2]int{size - 11 + k%3, k / 3})
+		}
+		if got := read(upper); got != specVersionInfo[version] {
+			t.Fatalf("upper version information = %s, want %s", got, specVersionInfo[version])
+		}
+		if got := read(lower); got != specVersionInfo[version] {
+			t.Fatalf("lower version information = %s, want %s", got, specVersionInfo[version])
+		}
+	}
+
+	// Modules that hold no data
+	reserved := make([][]bool, size)
+	for i := range reserved {
+		reserved[i] = make([]bool, size)
+	}
+	area := func(row, col, height, width int) {
+		for i := row; i < row+height; i++ {
+			for j := col; j < col+width; j++ {
+				reserved[i][j] = true
+			}
+		}
+	}
+	area(0, 0, 9, 9)      // Finder, separator and format
+	area(0, size-8, 9, 8) // Finder, separator and format
+	area(size-8, 0, 8, 9) // Finder, separator, format and dark module
+	area(6, 0, 1, size)
+	area(0, 6, size, 1)
+	centers := specAlignment[version]
+	for a, row := range centers {
+		for b, col := range centers {
+			last := len(centers) - 1
+			if (a == 0 && b == 0) || (a == 0 && b == last) || (a == last && b == 0) {
+				continue
+			}
+			for i := -2; i <= 2; i++ {
+				for j := -2; j <= 2; j++ {
+					if dark(row+i, col+j) != (max(abs(i), abs(j)) != 1) {
+						t.Fatalf("alignment pattern at (%d, %d) is wrong", row, col)
+					}
+				}
+			}
+			area(row-2, col-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		area(0, size-11, 6, 3)
+		area(size-11, 0, 3, 6)
+	}
+
+	// Unmask and read the bits in two-column strips, zigzagging from the bottom right
+	var bits []bool
+	upward := true
+	for right := size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for k := 0; k < size; k++ {
+			row := k
+			if upward {
+				row = size - 1 - k
+			}
+			for _, col := range []int{right, right - 1} {
+				if !reserved[row][col] {
+					bits = append(bits, dark(row, col) != specMasks[mask](row, col))
+				}
+			}
+		}
+		upward = !upward
+	}
+
+	total := specTotalCodewords[version]
+	if len(bits)/8 != total {
+		t.Fatalf("version %d has %d data bits, want %d codewords", version, len(bits), total)
+	}
+	codewords := make([]byte, total)
+	for k := range codewords {
+		for _, bit := range bits[8*k : 8*k+8] {
+			codewords[k] <<= 1
+			if bit {
+				codewords[k] |= 1
+			}
+		}
+	}
+
+	// De-interleave: data codewords block by block, then error correction codewords likewise
+	layout := specBlocks[version]
+	var blocks [][]byte
+	var dataLens []int
+	for _, group := range layout.groups {
+		for n := 0; n < group[0]; n++ {
+			dataLens = append(dataLens, group[1])
+			blocks = append(blocks, nil)
+		}
+	}
+	next := 0
+	for k := 0; k < dataLens[len(dataLens)-1]; k++ {
+		for b := range blocks {
+			if k < dataLens[b] {
+				blocks[b] = append(blocks[b], codewords[next])
+				next++
+			}
+		}
+	}
+	for k := 0; k < layout.ec; k++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[next])
+			next++
+		}
+	}
+
+	// Every syndrome of a valid block is zero
+	var data []byte
+	for b, block := range blocks {
+		for k := 0; k < layout.ec; k++ {
+			if s := syndrome(block, k); s != 0 {
+				t.Fatalf("block %d: syndrome %d = %#x", b, k, s)
+			}
+		}
+		data = append(data, block[:dataLens[b]]...)
+	}
+
+	// Byte mode segment, terminator and padding
+	stream := bitReader{data: data}
+	if mode := stream.read(4); mode != 0b0100 {
+		t.Fatalf("mode = %04b, want byte mode", mode)
+	}
+	countLen := 8
+	if version >= 10 {
+		countLen = 16
+	}
+	n := stream.read(countLen)
+	if 4+countLen+8*n > 8*len(data) {
+		t.Fatalf("count %d does not fit in version %d", n, version)
+	}
+	text := make([]byte, n)
+	for k := range text {
+		text[k] = byte(stream.read(8))
+	}
+	for stream.pos < 8*len(data) && (stream.pos%8 != 0 || stream.pos < 4+countLen+8*n+4) {
+		if stream.read(1) != 0 {
+			t.Fatalf("terminator bit %d is set", stream.pos-1)
+		}
+	}
+	for pad := byte(0xEC); stream.pos < 8*len(data); pad ^= 0xEC ^ 0x11 {
+		if got := byte(stream.read(8)); got != pad {
+			t.Fatalf("pad codeword = %#x, want %#x", got, pad)
+		}
+	}
+	return string(text)
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for ; n > 0; n-- {
+		v = v<<1 | int(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+// syndrome evaluates block, highest degree first, at alpha^k in GF(2^8) modulo 0x11D
+func syndrome(block []byte, k int) byte {
+	x := byte(1)
+	for ; k > 0; k-- {
+		x = gfDouble(x)
+	}
+	var s byte
+	for _, c := range block {
+		s = gfMul(s, x) ^ c
+	}
+	return s
+}
+
+func gfDouble(x byte) byte {
+	if x&0x80 != 0 {
+		return x<<1 ^ 0x1D
+	}
+	return x << 1
+}
+
+// gfMul multiplies by shifts and adds, independently of gfMultiply
+func gfMul(x, y byte) byte {
+	var z byte
+	for ; y != 0; y >>= 1 {
+		if y&1 != 0 {
+			z ^= x
+		}
+		x = gfDouble(x)
+	}
+	return z
+}
+
+func TestEncodeDecodes(t *testing.T) {
+	uri := "otpauth://totp/Groupie%20Tracker:alice?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP" +
+		"&issuer=Groupie%20Tracker&algorithm=SHA1&digits=6&period=30"
+
+	tests := []struct {
+		name        string
+		text        string
+		wantVersion int
+	}{
+		{"empty", "", 1},
+		{"short", "otpauth://totp", 1},
+		{"binary", "\x00\xff\x80é", 1},
+		{"otpauth uri without defaults", strings.TrimSuffix(uri, "&algorithm=SHA1&digits=6&period=30"), 6},
+		{"otpauth uri cut for version 7", uri[:122], 7},
+		{"otpauth uri", uri, 8},
+		{"otpauth uri for version 10", uri + strings.Repeat("&x=1", (213-len(uri))/4), 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.text)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if want := 17 + 4*tt.wantVersion; code.Size != want {
+				t.Errorf("Size = %d, want %d (version %d)", code.Size, want, tt.wantVersion)
+			}
+			if got := decode(t, code); got != tt.text {
+				t.Errorf("decoded %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	// Largest byte mode text of each version at level M
+	capacity := []int{1: 14, 2: 26, 3: 42, 4: 62, 5: 84, 6: 106, 7: 122, 8: 152, 9: 180, 10: 213}
+
+	for version := 1; version <= 10; version++ {
+		for _, n := range []int{capacity[version], capacity[version-1] + 1} {
+			text := strings.Repeat("otpauth:", n)[:n]
+			code, err := Encode(text)
+			if err != nil {
+				t.Fatalf("Encode(%d bytes) error = %v", n, err)
+			}
+			if want := 17 + 4*version; code.Size != want {
+				t.Errorf("Encode(%d bytes): Size = %d, want %d", n, code.Size, want)
+			}
+			if got := decode(t, code); got != text {
+				t.Errorf("Encode(%d bytes) decoded %q", n, got)
+			}
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("a", capacity[10]+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Encode(%d bytes) error = %v, want ErrTooLong", capacity[10]+1, err)
+	}
+}
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The version 1-M example of ISO/IEC 18004 Annex I, encoding "01234567"
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("reedSolomonRemainder() = % X, want % X", got, want)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code := &Code{Size: 2, Modules: [][]bool{{true, false}, {false, true}}}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="30" height="30" viewBox="0 0 10 10"` +
+		` shape-rendering="crispEdges" role="img" aria-label="QR code">` +
+		`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="M4,4h1v1h-1zM5,5h1v1h-1z"/></svg>`
+
+	if got := code.SVG(3); got != want {
+		t.Errorf("SVG() = %s, want %s", got, want)
+	}
+}
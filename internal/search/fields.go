@@ -0,0 +1,244 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/index"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// Score of a free text term found in a concert place or date rather than in a name
+const concertScore = 200
+
+// fieldSpec describes a "field:" prefix. Text fields read Term.Text, numeric ones Term.Range.
+type fieldSpec struct {
+	numeric bool
+	indexed index.Field // Index fields narrowing the candidates of a text field, 0 for none
+	match   func(t boundTerm, artist util.Artist) bool
+}
+
+// FieldNames lists the field prefixes in the order shown by the help
+var FieldNames = []string{"name", "member", "location", "date", "members", "created", "album", "concerts"}
+
+var fields = map[string]fieldSpec{
+	// Free text: name or one of the members, tolerating typos, or a concert place or date
+	"": {indexed: index.FieldText, match: func(t boundTerm, artist util.Artist) bool {
+		return t.score(artist).Score > 0
+	}},
+	"name": {indexed: index.FieldName, match: func(t boundTerm, artist util.Artist) bool {
+		return t.score(artist).Score > 0
+	}},
+	"member": {indexed: index.FieldMember, match: func(t boundTerm, artist util.Artist) bool {
+		return t.score(artist).Score > 0
+	}},
+	// A concert location: slug, city, region, country or continent
+	"location": {indexed: index.FieldPlace, match: func(t boundTerm, artist util.Artist) bool {
+		if t.candidates != nil && !t.candidates.Has(artist.ID) {
+			return false
+		}
+		for _, concert := range t.catalog.ArtistConcerts(artist.ID) {
+			location := concert.Location
+			if strings.Contains(location.Slug, t.Text) || containsFolded(location.Query(), t.Text) {
+				return true
+			}
+		}
+		return false
+	}},
+	// A concert date or its start: 2019, 2019-06, 2019-06-21
+	"date": {match: func(t boundTerm, artist util.Artist) bool {
+		if t.dates != nil && !t.dates.Has(artist.ID) {
+			return false
+		}
+		for _, concert := range t.catalog.ArtistConcerts(artist.ID) {
+			if strings.HasPrefix(concert.Date.Format("2006-01-02"), t.Text) {
+				return true
+			}
+		}
+		return false
+	}},
+	"members": {numeric: true, match: func(t boundTerm, artist util.Artist) bool {
+		return t.Range.Contains(len(artist.Members))
+	}},
+	"created": {numeric: true, match: func(t boundTerm, artist util.Artist) bool {
+		return t.Range.Contains(artist.CreationDate)
+	}},
+	"album": {numeric: true, match: func(t boundTerm, artist util.Artist) bool {
+		year, ok := filter.AlbumYear(artist)
+		return ok && t.Range.Contains(year)
+	}},
+	"concerts": {numeric: true, match: func(t boundTerm, artist util.Artist) bool {
+		return t.Range.Contains(len(t.catalog.ArtistConcerts(artist.ID)))
+	}},
+}
+
+// boundTerm is a term resolved against a catalog snapshot and its index.
+// The index sets only rule artists out; the others are still checked with the rules
+// below, so results are the same with or without index (nil sets).
+type boundTerm struct {
+	Term
+	catalog    *util.CatalogSnapshot
+	candidates index.Set // Artists the index found for a text field
+	concerts   index.Set // Free text: artists with a concert place or date matching it
+	dates      index.Set // date: artists with a concert on that date
+}
+
+func (q Query) bind(catalog *util.CatalogSnapshot) [][]boundTerm {
+	ix := index.For(catalog)
+	clauses := make([][]boundTerm, len(q.Clauses))
+	for i, clause := range q.Clauses {
+		for _, term := range clause {
+			bound := boundTerm{Term: term, catalog: catalog}
+			if words := indexWords(term.Text); ix != nil && words != "" {
+				if spec := fields[term.Field]; spec.indexed != 0 {
+					bound.candidates = ix.Search(words, spec.indexed)
+				}
+				switch term.Field {
+				case "":
+					bound.concerts = ix.Search(words, index.FieldPlace|index.FieldDate)
+				case "date":
+					bound.dates = ix.Prefix(term.Text, index.FieldConcert)
+				}
+			}
+			clauses[i] = append(clauses[i], bound)
+		}
+	}
+	return clauses
+}
+
+// indexWords splits text on everything but letters and digits, like the indexed terms.
+// A substring of a place or date then only has words found inside the index terms,
+// so the index never rules out an artist the rules would keep ("seattle, usa").
+func indexWords(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Predicate turns the query into an artist criterion over a catalog snapshot
+func (q Query) Predicate(catalog *util.CatalogSnapshot) filter.ArtistPredicate {
+	bound := q.bind(catalog)
+	clauses := make([]filter.ArtistPredicate, len(bound))
+	for i, clause := range bound {
+		alternatives := make([]filter.ArtistPredicate, len(clause))
+		for j, term := range clause {
+			alternatives[j] = term.predicate()
+		}
+		clauses[i] = filter.AnyArtist(alternatives...)
+	}
+	return filter.AllArtists(clauses...)
+}
+
+func (t boundTerm) predicate() filter.ArtistPredicate {
+	match := fields[t.Field].match
+	predicate := func(artist util.Artist) bool {
+		return match(t, artist)
+	}
+	if t.Negated {
+		return filter.NotArtist(predicate)
+	}
+	return predicate
+}
+
+// score rates how well a free text, name or member term matches an artist (zero score when it does not)
+func (t boundTerm) score(artist util.Artist) util.ArtistMatch {
+	inText := t.candidates == nil || t.candidates.Has(artist.ID)
+
+	switch t.Field {
+	case "":
+		if inText {
+			if match, ok := util.MatchArtist(artist, t.Text); ok {
+				return match
+			}
+		}
+		if t.concertMatch(artist) {
+			return util.ArtistMatch{Score: concertScore, Field: "concert"}
+		}
+	case "name":
+		if inText {
+			match := util.MatchText(artist.Name, t.Text)
+			return util.ArtistMatch{Score: util.NameScore(match), Field: "name", Text: artist.Name, Span: match.Span}
+		}
+	case "member":
+		best := util.ArtistMatch{}
+		for _, member := range artist.Members {
+			if !inText {
+				break
+			}
+			match := util.MatchText(member, t.Text)
+			if score := util.MemberScore(match); score > best.Score {
+				best = util.ArtistMatch{Score: score, Field: "member", Text: member, Span: match.Span}
+			}
+		}
+		return best
+	}
+	return util.ArtistMatch{}
+}
+
+// concertMatch reports whether every word of a free text term appears in a concert place or date,
+// without typo tolerance
+func (t boundTerm) concertMatch(artist util.Artist) bool {
+	if t.concerts != nil && !t.concerts.Has(artist.ID) {
+		return false
+	}
+
+	concerts := t.catalog.ArtistConcerts(artist.ID)
+	for _, word := range strings.Fields(t.Text) {
+		found := false
+		for _, concert := range concerts {
+			location := concert.Location
+			if strings.Contains(location.Slug, word) || containsFolded(location.Query(), word) ||
+				containsFolded(location.Continent, word) || strings.Contains(concert.Date.Format("2006-01-02"), word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(concerts) > 0
+}
+
+// Rank orders matches by relevance to the text terms of the query, most relevant first.
+// It returns the best match of each artist, keyed by artist ID, to highlight it.
+func (q Query) Rank(catalog *util.CatalogSnapshot, matches []filter.Match) map[int]util.ArtistMatch {
+	bound := q.bind(catalog)
+	scores := make(map[int]int, len(matches))
+	best := make(map[int]util.ArtistMatch, len(matches))
+	for _, match := range matches {
+		id := match.Artist.ID
+		for _, clause := range bound {
+			// A clause counts for its best alternative
+			clauseBest := util.ArtistMatch{}
+			for _, term := range clause {
+				if term.Negated {
+					continue
+				}
+				if hit := term.score(match.Artist); hit.Score > clauseBest.Score {
+					clauseBest = hit
+				}
+			}
+			scores[id] += clauseBest.Score
+			if clauseBest.Score > best[id].Score {
+				best[id] = clauseBest
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i].Artist.ID] > scores[matches[j].Artist.ID]
+	})
+	return best
+}
+
+// foldQuery prepares a text value for containsFolded
+func foldQuery(value string) string {
+	return util.FoldText(strings.TrimSpace(value))
+}
+
+func containsFolded(s, folded string) bool {
+	return strings.Contains(util.FoldText(s), folded)
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// token is one whitespace-separated term of a query, quotes removed
+type token struct {
+	pos    int // Column of the first rune, from 1
+	negate bool
+	field  string // Lowercase, "" for free text
+	value  string
+	quoted bool
+	or     bool // The OR keyword
+}
+
+// lex splits a query into tokens. Quoted phrases keep their spaces.
+func lex(input string) ([]token, Errors) {
+	runes := []rune(input)
+	var tokens []token
+	var errs Errors
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		tok := token{pos: i + 1}
+		if runes[i] == '-' {
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				errs.add(tok.pos, "Le signe « - » doit être collé au terme à exclure")
+				i++
+				continue
+			}
+			tok.negate = true
+			i++
+		}
+
+		// Optional "field:" prefix
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || runes[j] == '_') {
+			j++
+		}
+		if j > i && j < len(runes) && runes[j] == ':' {
+			tok.field = strings.ToLower(string(runes[i:j]))
+			i = j + 1
+		}
+
+		// Value: a quoted phrase or everything up to the next space
+		var value strings.Builder
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] != '"' {
+				value.WriteRune(runes[i])
+				i++
+				continue
+			}
+
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				errs.add(i+1, "Guillemet ouvert mais jamais fermé")
+			}
+			value.WriteString(string(runes[i+1 : end]))
+			tok.quoted = true
+			i = end + 1
+		}
+		tok.value = value.String()
+
+		tok.or = tok.value == "OR" && tok.field == "" && !tok.negate && !tok.quoted
+		tokens = append(tokens, tok)
+	}
+	return tokens, errs
+}
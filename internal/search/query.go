@@ -0,0 +1,204 @@
+// Package search parses the query language of the search page:
+//
+//	queen member:"brian may" -location:usa created:>1990 album:1970..1979 concerts:>=10 soja OR scorpions
+//
+// Terms separated by spaces must all match; OR joins alternatives and binds tighter than the space.
+// A leading minus excludes a term, quotes keep a phrase together.
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error is a syntax error in a query
+type Error struct {
+	Position int    // Column, from 1
+	Message  string // French, shown to the user
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("colonne %d : %s", e.Position, e.Message)
+}
+
+// Errors lists the syntax errors of a query
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *Errors) add(pos int, format string, args ...any) {
+	*e = append(*e, Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// Range is an inclusive numeric interval; open bounds are math.MinInt and math.MaxInt
+type Range struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether value is in the range
+func (r Range) Contains(value int) bool {
+	return value >= r.Min && value <= r.Max
+}
+
+// Term is one criterion of a query
+type Term struct {
+	Field   string // "" searches names and members
+	Text    string // Value of text fields, folded
+	Range   Range  // Value of numeric fields
+	Negated bool
+}
+
+// Query is a parsed query: every clause must match, a clause matches when one of its terms does
+type Query struct {
+	Clauses [][]Term
+}
+
+// Empty reports whether the query has no term
+func (q Query) Empty() bool {
+	return len(q.Clauses) == 0
+}
+
+// Parse reads a query. Every syntax error is reported, the Query is only usable when there is none.
+func Parse(input string) (Query, Errors) {
+	tokens, errs := lex(input)
+
+	var query Query
+	pendingOr := false
+	for _, tok := range tokens {
+		if tok.or {
+			if len(query.Clauses) == 0 || pendingOr {
+				errs.add(tok.pos, "OR doit relier deux termes")
+				continue
+			}
+			pendingOr = true
+			continue
+		}
+
+		term, ok := newTerm(tok, &errs)
+		if !ok {
+			pendingOr = false
+			continue
+		}
+		if pendingOr {
+			last := len(query.Clauses) - 1
+			query.Clauses[last] = append(query.Clauses[last], term)
+		} else {
+			query.Clauses = append(query.Clauses, []Term{term})
+		}
+		pendingOr = false
+	}
+	if pendingOr {
+		errs.add(len([]rune(input)), "OR doit être suivi d'un terme")
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Position < errs[j].Position })
+	return query, errs
+}
+
+func newTerm(tok token, errs *Errors) (Term, bool) {
+	term := Term{Field: tok.field, Negated: tok.negate}
+
+	spec, ok := fields[tok.field]
+	if !ok {
+		errs.add(tok.pos, "Champ inconnu « %s: » (champs possibles : %s)", tok.field, strings.Join(FieldNames, ", "))
+		return term, false
+	}
+	if tok.value == "" {
+		if tok.field == "" {
+			errs.add(tok.pos, "Terme vide")
+		} else {
+			errs.add(tok.pos, "Valeur manquante après « %s: »", tok.field)
+		}
+		return term, false
+	}
+
+	if !spec.numeric {
+		term.Text = foldQuery(tok.value)
+		return term, true
+	}
+
+	r, err := parseRange(tok.value)
+	if err != "" {
+		errs.add(tok.pos, "%s: %s", tok.field, err)
+		return term, false
+	}
+	term.Range = r
+	return term, true
+}
+
+// parseRange reads "1990", ">1990", ">=1990", "<10", "<=10", "1970..1979", "1970.." or "..1979".
+// It returns a French error message when the value is invalid.
+func parseRange(value string) (Range, string) {
+	r := Range{Min: math.MinInt, Max: math.MaxInt}
+
+	number := func(s string) (int, bool) {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		return n, err == nil
+	}
+
+	if from, to, found := strings.Cut(value, ".."); found {
+		if from == "" && to == "" {
+			return r, "intervalle sans bornes"
+		}
+		if from != "" {
+			n, ok := number(from)
+			if !ok {
+				return r, fmt.Sprintf("« %s » n'est pas un nombre", from)
+			}
+			r.Min = n
+		}
+		if to != "" {
+			n, ok := number(to)
+			if !ok {
+				return r, fmt.Sprintf("« %s » n'est pas un nombre", to)
+			}
+			r.Max = n
+		}
+		if r.Min > r.Max {
+			return r, fmt.Sprintf("intervalle « %s » inversé", value)
+		}
+		return r, ""
+	}
+
+	operator := ""
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			operator = op
+			break
+		}
+	}
+	n, ok := number(strings.TrimPrefix(value, operator))
+	if !ok {
+		return r, fmt.Sprintf("« %s » n'est pas un nombre (exemples : 1990, >1990, 1970..1979)", value)
+	}
+
+	switch operator {
+	case ">=":
+		r.Min = n
+	case "<=":
+		r.Max = n
+	case ">":
+		if n == math.MaxInt { // n + 1 would wrap around and match everything
+			return r, fmt.Sprintf("aucun nombre ne vérifie « %s »", value)
+		}
+		r.Min = n + 1
+	case "<":
+		if n == math.MinInt {
+			return r, fmt.Sprintf("aucun nombre ne vérifie « %s »", value)
+		}
+		r.Max = n - 1
+	default:
+		r.Min, r.Max = n, n
+	}
+	return r, ""
+}
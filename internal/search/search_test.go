@@ -0,0 +1,171 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/YajiTV/groupie-tracker/internal/filter"
+	"github.com/YajiTV/groupie-tracker/internal/geo"
+	"github.com/YajiTV/groupie-tracker/internal/index"
+	"github.com/YajiTV/groupie-tracker/internal/util"
+)
+
+// fixtureDir is a small API snapshot, in the format saved by cmd/snapshot
+const fixtureDir = "testdata/catalog"
+
+// benchScale is how many copies of the fixture the benchmarks search
+const benchScale = 200
+
+// scaleIDStride separates the artist IDs of each copy of a scaled catalog
+const scaleIDStride = 1_000_000
+
+var benchQueries = []string{
+	"queen", "beyonce", "qeen", "freddie mercury", "osaka",
+	"location:usa", "location:pariss", "2019", "date:2019-06", "date:21",
+}
+
+func TestIndexedSearchMatchesLinear(t *testing.T) {
+	snapshot := loadFixture(t, 1)
+	ix := index.Build(snapshot)
+	t.Cleanup(func() { index.Publish(nil) })
+
+	queries := append(benchQueries,
+		"location:\"washington, usa\"", "21-08", "pariss", "los-angeles", "north america", "-queen", "member:roger OR name:soja")
+	for _, input := range queries {
+		query, errs := Parse(input)
+		if errs != nil {
+			t.Fatalf("Parse(%q): %v", input, errs)
+		}
+
+		index.Publish(nil)
+		linear := matchIDs(runSearch(snapshot, query))
+		index.Publish(ix)
+		indexed := matchIDs(runSearch(snapshot, query))
+
+		if !equalInts(linear, indexed) {
+			t.Errorf("%q: linear scan found %v, index found %v", input, linear, indexed)
+		}
+	}
+}
+
+func BenchmarkSearchLinear(b *testing.B) {
+	benchmarkSearch(b, false)
+}
+
+func BenchmarkSearchIndexed(b *testing.B) {
+	benchmarkSearch(b, true)
+}
+
+// benchmarkSearch runs the search page path over a scaled fixture, with or without a published index
+func benchmarkSearch(b *testing.B, indexed bool) {
+	snapshot := loadFixture(b, benchScale)
+	if indexed {
+		index.Publish(index.Build(snapshot))
+		b.Cleanup(func() { index.Publish(nil) })
+	}
+
+	for _, input := range benchQueries {
+		query, errs := Parse(input)
+		if errs != nil {
+			b.Fatalf("Parse(%q): %v", input, errs)
+		}
+		b.Run(input, func(b *testing.B) {
+			for range b.N {
+				runSearch(snapshot, query)
+			}
+		})
+	}
+}
+
+// runSearch does what the search page does with a query
+func runSearch(snapshot *util.CatalogSnapshot, query Query) []filter.Match {
+	matches := filter.NewEngine(snapshot, geo.Default).Match(filter.Filter{Where: query.Predicate(snapshot)})
+	query.Rank(snapshot, matches)
+	return matches
+}
+
+func loadFixture(tb testing.TB, copies int) *util.CatalogSnapshot {
+	tb.Helper()
+	cache := &util.CatalogCache{}
+	cache.SetSource(scaledSource{source: util.NewFileSource(fixtureDir), copies: copies})
+	if err := cache.Load(); err != nil {
+		tb.Fatal(err)
+	}
+	return cache.Snapshot()
+}
+
+func matchIDs(matches []filter.Match) []int {
+	ids := make([]int, len(matches))
+	for i, match := range matches {
+		ids[i] = match.Artist.ID
+	}
+	return ids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// scaledSource repeats every artist of a source with new IDs
+type scaledSource struct {
+	source util.DataSource
+	copies int
+}
+
+func (s scaledSource) Artists() ([]util.Artist, error) {
+	artists, err := s.source.Artists()
+	if err != nil {
+		return nil, err
+	}
+	var scaled []util.Artist
+	for k := range s.copies {
+		for _, artist := range artists {
+			artist.ID += k * scaleIDStride
+			scaled = append(scaled, artist)
+		}
+	}
+	return scaled, nil
+}
+
+func (s scaledSource) Locations() (util.LocationResponse, error) {
+	locations, err := s.source.Locations()
+	var scaled util.LocationResponse
+	for k := range s.copies {
+		for _, data := range locations.Index {
+			data.ID += k * scaleIDStride
+			scaled.Index = append(scaled.Index, data)
+		}
+	}
+	return scaled, err
+}
+
+func (s scaledSource) Relations() (util.RelationResponse, error) {
+	relations, err := s.source.Relations()
+	var scaled util.RelationResponse
+	for k := range s.copies {
+		for _, data := range relations.Index {
+			data.ID += k * scaleIDStride
+			scaled.Index = append(scaled.Index, data)
+		}
+	}
+	return scaled, err
+}
+
+func (s scaledSource) Dates() (util.DatesResponse, error) {
+	dates, err := s.source.Dates()
+	var scaled util.DatesResponse
+	for k := range s.copies {
+		for _, data := range dates.Index {
+			data.ID += k * scaleIDStride
+			scaled.Index = append(scaled.Index, data)
+		}
+	}
+	return scaled, err
+}
@@ -128,3 +128,20 @@ func saveFav(data favoritesData) error {
 	enc.SetIndent("", "  ")
 	return enc.Encode(data)
 }
+
+// FavoriteCounts returns how many users favorited each artist, keyed by artist ID
+func FavoriteCounts() (map[int]int, error) {
+	favMutex.RLock()
+	defer favMutex.RUnlock()
+
+	data, err := loadFav()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int)
+	for _, f := range data.Favorites {
+		counts[f.ArtistID]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// PasswordReset is a pending password reset. Only the hash of the emailed token is kept.
+type PasswordReset struct {
+	TokenHash string    `json:"token_hash"`
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type passwordResetsData struct {
+	Resets []PasswordReset `json:"resets"`
+}
+
+// ErrResetNotFound is returned for unknown, used or expired reset tokens
+var ErrResetNotFound = errors.New("lien de réinitialisation invalide ou expiré")
+
+var (
+	resetFile  = "data/password_resets.json"
+	resetMutex sync.Mutex
+)
+
+// AddPasswordReset stores a reset, replacing the previous ones of the user
+// so only the latest emailed link works
+func AddPasswordReset(reset PasswordReset) error {
+	resetMutex.Lock()
+	defer resetMutex.Unlock()
+
+	data, err := loadResets()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	kept := data.Resets[:0]
+	for _, r := range data.Resets {
+		if r.UserID != reset.UserID && now.Before(r.ExpiresAt) {
+			kept = append(kept, r)
+		}
+	}
+	data.Resets = append(kept, reset)
+	return saveJSON(resetFile, data)
+}
+
+// FindPasswordReset returns the valid reset of a token hash without using it
+func FindPasswordReset(tokenHash string) (PasswordReset, error) {
+	resetMutex.Lock()
+	defer resetMutex.Unlock()
+
+	data, err := loadResets()
+	if err != nil {
+		return PasswordReset{}, err
+	}
+
+	for _, r := range data.Resets {
+		if r.TokenHash == tokenHash && time.Now().Before(r.ExpiresAt) {
+			return r, nil
+		}
+	}
+	return PasswordReset{}, ErrResetNotFound
+}
+
+// TakePasswordReset returns the valid reset of a token hash and deletes it: tokens are single-use
+func TakePasswordReset(tokenHash string) (PasswordReset, error) {
+	resetMutex.Lock()
+	defer resetMutex.Unlock()
+
+	data, err := loadResets()
+	if err != nil {
+		return PasswordReset{}, err
+	}
+
+	for i, r := range data.Resets {
+		if r.TokenHash != tokenHash {
+			continue
+		}
+		data.Resets = append(data.Resets[:i], data.Resets[i+1:]...)
+		if err := saveJSON(resetFile, data); err != nil {
+			return PasswordReset{}, err
+		}
+		if !time.Now().Before(r.ExpiresAt) {
+			return PasswordReset{}, ErrResetNotFound
+		}
+		return r, nil
+	}
+	return PasswordReset{}, ErrResetNotFound
+}
+
+// loadResets reads the resets file, empty when it does not exist yet
+func loadResets() (passwordResetsData, error) {
+	var data passwordResetsData
+	err := loadJSON(resetFile, &data)
+	if errors.Is(err, os.ErrNotExist) {
+		return passwordResetsData{}, nil
+	}
+	if err != nil {
+		return passwordResetsData{}, err
+	}
+	return data, nil
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/YajiTV/groupie-tracker/internal/models"
 )
@@ -97,6 +99,21 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return nil, errors.New("utilisateur introuvable")
 }
 
+// GetUserByEmail retrieves a user by email, ignoring case
+func GetUserByEmail(email string) (*models.User, error) {
+	users, err := GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("utilisateur introuvable")
+}
+
 // CreateUser creates a new user
 func CreateUser(user models.User) (*models.User, error) {
 	userMutex.Lock()
@@ -107,12 +124,13 @@ func CreateUser(user models.User) (*models.User, error) {
 		return nil, err
 	}
 
-	// Check if username already exists
+	// Check if username already exists, ignoring case: login throttling counts case variants together
 	for _, u := range userData.Users {
-		if u.Username == user.Username {
+		if strings.EqualFold(u.Username, user.Username) {
 			return nil, errors.New("nom d'utilisateur déjà pris")
 		}
-		if u.Email == user.Email {
+		// Addresses differing only by case reach the same mailbox, GetUserByEmail treats them as one
+		if strings.EqualFold(u.Email, user.Email) {
 			return nil, errors.New("email déjà utilisé")
 		}
 	}
@@ -148,3 +166,47 @@ func UpdateUser(user models.User) error {
 
 	return errors.New("utilisateur introuvable")
 }
+
+// ModifyUser applies change to the stored user and saves it under the lock, so fields
+// changed meanwhile by other requests are kept. Nothing is saved if change fails.
+func ModifyUser(id int, change func(user *models.User) error) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	var userData models.UserData
+	if err := loadJSON(UsersFile, &userData); err != nil {
+		return err
+	}
+
+	for i := range userData.Users {
+		if userData.Users[i].ID == id {
+			if err := change(&userData.Users[i]); err != nil {
+				return err
+			}
+			return saveJSON(UsersFile, userData)
+		}
+	}
+
+	return errors.New("utilisateur introuvable")
+}
+
+// RecordLoginFailure counts a failed login on a user, and locks the account if lockedUntil is set
+func RecordLoginFailure(id int, at, lockedUntil time.Time) error {
+	return ModifyUser(id, func(user *models.User) error {
+		user.FailedLogins++
+		user.LastFailedLogin = at
+		if !lockedUntil.IsZero() {
+			user.LockedUntil = lockedUntil
+		}
+		return nil
+	})
+}
+
+// ResetLoginFailures clears the failed logins and the lock of a user
+func ResetLoginFailures(id int) error {
+	return ModifyUser(id, func(user *models.User) error {
+		user.FailedLogins = 0
+		user.LockedUntil = time.Time{}
+		return nil
+	})
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YajiTV/groupie-tracker/internal/models"
+)
+
+// useTempUsers points UsersFile at an empty file for the length of the test
+func useTempUsers(t *testing.T) {
+	t.Helper()
+	old := UsersFile
+	UsersFile = filepath.Join(t.TempDir(), "users.json")
+	t.Cleanup(func() { UsersFile = old })
+
+	if err := saveJSON(UsersFile, models.UserData{Users: []models.User{}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoginFailuresKeepOtherChanges(t *testing.T) {
+	useTempUsers(t)
+
+	created, err := CreateUser(models.User{Username: "alice", Email: "alice@example.com", Password: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed login loaded the user, then the password changed before the failure was saved
+	stale, err := GetUserByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ModifyUser(created.ID, func(user *models.User) error {
+		user.Password = "new"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lockedUntil := at.Add(15 * time.Minute)
+	if err := RecordLoginFailure(stale.ID, at, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordLoginFailure(stale.ID, at, lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := GetUserByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "new" {
+		t.Errorf("Password = %q, want %q", user.Password, "new")
+	}
+	if user.FailedLogins != 2 || !user.LastFailedLogin.Equal(at) || !user.LockedUntil.Equal(lockedUntil) {
+		t.Errorf("failures = (%d, %v, %v), want (2, %v, %v)",
+			user.FailedLogins, user.LastFailedLogin, user.LockedUntil, at, lockedUntil)
+	}
+
+	if err := ResetLoginFailures(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	user, err = GetUserByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.FailedLogins != 0 || !user.LockedUntil.IsZero() || user.Password != "new" {
+		t.Errorf("after reset: got (%d, %v, %q), want (0, zero, %q)",
+			user.FailedLogins, user.LockedUntil, user.Password, "new")
+	}
+}
+
+func TestModifyUserSavesNothingOnError(t *testing.T) {
+	useTempUsers(t)
+
+	created, err := CreateUser(models.User{Username: "alice", Email: "alice@example.com", Bio: "before"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errRefused := errors.New("refused")
+	err = ModifyUser(created.ID, func(user *models.User) error {
+		user.Bio = "after"
+		return errRefused
+	})
+	if err != errRefused {
+		t.Fatalf("ModifyUser() = %v, want %v", err, errRefused)
+	}
+
+	user, err := GetUserByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Bio != "before" {
+		t.Errorf("Bio = %q, want %q", user.Bio, "before")
+	}
+
+	if err := ModifyUser(created.ID+1, func(*models.User) error { return nil }); err == nil {
+		t.Error("ModifyUser() of an unknown user succeeded")
+	}
+}
+
+func TestCreateUserRejectsCaseVariants(t *testing.T) {
+	useTempUsers(t)
+
+	if _, err := CreateUser(models.User{Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		username string
+		email    string
+		wantErr  bool
+	}{
+		{"Alice", "other@example.com", true},
+		{"ALICE", "other@example.com", true},
+		{"bob", "Alice@Example.com", true},
+		{"bob", "bob@example.com", false},
+	}
+	for _, tt := range tests {
+		_, err := CreateUser(models.User{Username: tt.username, Email: tt.email})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CreateUser(%q, %q) error = %v, wantErr %v", tt.username, tt.email, err, tt.wantErr)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package templates
 
-import "html/template"
+import (
+	"html/template"
+
+	"github.com/YajiTV/groupie-tracker/internal/auth"
+)
 
 // TemplateFuncs returns custom functions for templates
 func TemplateFuncs() template.FuncMap {
 	return template.FuncMap{
-		"iterate": iterate,
+		"iterate":   iterate,
+		"highlight": highlight,
+		"csrfField": csrfField,
 	}
 }
 
@@ -22,3 +28,20 @@ func iterate(start, end int) []int {
 	}
 	return result
 }
+
+// highlight escapes text and wraps the bytes [start, end) in <mark>
+// Usage in template: {{highlight .Text .Span.Start .Span.End}}
+func highlight(text string, start, end int) template.HTML {
+	if start < 0 || end > len(text) || start >= end {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+	return template.HTML(template.HTMLEscapeString(text[:start]) +
+		`<mark class="bg-white/20 text-white rounded px-0.5">` + template.HTMLEscapeString(text[start:end]) + "</mark>" +
+		template.HTMLEscapeString(text[end:]))
+}
+
+// csrfField renders the hidden CSRF token input of a form
+// Usage in template: {{csrfField .CSRFToken}}
+func csrfField(token string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + auth.CSRFFieldName + `" value="` + template.HTMLEscapeString(token) + `">`)
+}
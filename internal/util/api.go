@@ -1,18 +1,10 @@
 package util
 
 import (
-	"encoding/json"
-	"net/http"
 	"net/url"
 	//"strconv"
 )
 
-const (
-	ArtistsURL   = "https://groupietrackers.herokuapp.com/api/artists"
-	LocationsURL = "https://groupietrackers.herokuapp.com/api/locations"
-	RelationURL  = "https://groupietrackers.herokuapp.com/api/relation"
-)
-
 type Artist struct {
 	ID           int      `json:"id"`
 	Image        string   `json:"image"`
@@ -40,9 +32,21 @@ type RelationResponse struct {
 	Index []RelationData `json:"index"`
 }
 
+// DatesData holds an artist's concert dates, grouped by location in the
+// order of LocationData.Locations; "*" marks the first date of each group
+type DatesData struct {
+	ID    int      `json:"id"`
+	Dates []string `json:"dates"`
+}
+
+type DatesResponse struct {
+	Index []DatesData `json:"index"`
+}
+
 // Structure to pass location data to the map
 type ArtistLocation struct {
 	Name  string   `json:"name"`
+	Place Location `json:"place"`
 	Dates []string `json:"dates"`
 }
 
@@ -57,68 +61,8 @@ func (a Artist) GetSpotifyURL() string {
 	return "https://open.spotify.com/search/" + encodedName
 }
 
-func FetchArtists() ([]Artist, error) {
-	resp, err := http.Get(ArtistsURL)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var artists []Artist
-	json.NewDecoder(resp.Body).Decode(&artists)
-	return artists, nil
-}
-
-func FetchArtistByID(id int) (Artist, error) {
-	resp, err := http.Get(ArtistsURL)
-	if err != nil {
-		return Artist{}, err
-	}
-	defer resp.Body.Close()
-
-	var artists []Artist
-	json.NewDecoder(resp.Body).Decode(&artists)
-
-	for _, artist := range artists {
-		if artist.ID == id {
-			return artist, nil
-		}
-	}
-	return Artist{}, err
-}
-
-func FetchArtistWithLocations(id int) (ArtistWithLocations, error) {
-	// 1. Retrieve artist
-	artist, err := FetchArtistByID(id)
-	if err != nil {
-		return ArtistWithLocations{}, err
-	}
-
-	// 2. Retrieve locations
-	locResp, err := http.Get(LocationsURL)
-	if err != nil {
-		return ArtistWithLocations{Artist: artist}, err
-	}
-	defer locResp.Body.Close()
-
-	var locationResponse LocationResponse
-	if err := json.NewDecoder(locResp.Body).Decode(&locationResponse); err != nil {
-		return ArtistWithLocations{Artist: artist}, err
-	}
-
-	// 3. Retrieve relations (dates per location)
-	relResp, err := http.Get(RelationURL)
-	if err != nil {
-		return ArtistWithLocations{Artist: artist}, err
-	}
-	defer relResp.Body.Close()
-
-	var relationResponse RelationResponse
-	if err := json.NewDecoder(relResp.Body).Decode(&relationResponse); err != nil {
-		return ArtistWithLocations{Artist: artist}, err
-	}
-
-	// 4. Find data for this artist
+// joinArtistLocations builds the list of an artist's locations with their dates
+func joinArtistLocations(id int, locationResponse LocationResponse, relationResponse RelationResponse) []ArtistLocation {
 	var locations []ArtistLocation
 
 	// Find artist's locations
@@ -138,6 +82,7 @@ func FetchArtistWithLocations(id int) (ArtistWithLocations, error) {
 				dates := datesLocations[locName]
 				locations = append(locations, ArtistLocation{
 					Name:  locName,
+					Place: ParseLocation(locName),
 					Dates: dates,
 				})
 			}
@@ -145,38 +90,5 @@ func FetchArtistWithLocations(id int) (ArtistWithLocations, error) {
 		}
 	}
 
-	return ArtistWithLocations{
-		Artist:    artist,
-		Locations: locations,
-	}, nil
-}
-
-func FetchLocations() (LocationResponse, error) {
-	resp, err := http.Get(LocationsURL)
-	if err != nil {
-		return LocationResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	var locationResponse LocationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&locationResponse); err != nil {
-		return LocationResponse{}, err
-	}
-
-	return locationResponse, nil
-}
-
-func FetchRelations() (RelationResponse, error) {
-	resp, err := http.Get(RelationURL)
-	if err != nil {
-		return RelationResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	var relationResponse RelationResponse
-	if err := json.NewDecoder(resp.Body).Decode(&relationResponse); err != nil {
-		return RelationResponse{}, err
-	}
-
-	return relationResponse, nil
+	return locations
 }
@@ -0,0 +1,285 @@
+package util
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCatalogUnavailable is returned when no snapshot has ever been loaded
+var ErrCatalogUnavailable = errors.New("catalog unavailable")
+
+// CatalogSnapshot is an immutable copy of the upstream data.
+// Readers share it, so its slices and maps must never be modified.
+type CatalogSnapshot struct {
+	Artists   []Artist
+	Locations LocationResponse
+	Relations RelationResponse
+	Dates     DatesResponse
+	FetchedAt time.Time
+
+	// Concerts merged from relations and dates, sorted by artist then date
+	Concerts  []Concert
+	Conflicts []ConcertConflict
+
+	artistsByID     map[int]int        // artist ID -> index in Artists
+	artistLocations map[int][]Location // artist ID -> concert locations
+	artistConcerts  map[int][]Concert  // artist ID -> sub-slice of Concerts
+}
+
+// CatalogCache keeps the latest snapshot in memory and refreshes it in the background
+type CatalogCache struct {
+	source    DataSource
+	current   atomic.Pointer[CatalogSnapshot]
+	loadMutex sync.Mutex // Serializes loads, readers never take it
+	stop      chan struct{}
+	stopInit  sync.Once // Creates stop, so Start and Stop can race
+	stopOnce  sync.Once // Closes stop, so Stop can be called twice
+	onLoad    []func(*CatalogSnapshot)
+}
+
+// Catalog is the global catalog cache
+var Catalog = &CatalogCache{
+	source: NewHTTPSource(DefaultBaseURL),
+}
+
+// SetSource changes where the catalog loads its data from
+func (c *CatalogCache) SetSource(source DataSource) {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+	c.source = source
+}
+
+// OnLoad registers a function called with every new snapshot, while the load lock is held.
+// Slow work should be started in its own goroutine.
+func (c *CatalogCache) OnLoad(fn func(*CatalogSnapshot)) {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+	c.onLoad = append(c.onLoad, fn)
+}
+
+// Load reads artists, locations, relations and dates from the source and swaps the snapshot.
+// On error the previous snapshot is kept.
+func (c *CatalogCache) Load() error {
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+
+	return c.load()
+}
+
+func (c *CatalogCache) load() error {
+	artists, err := c.source.Artists()
+	if err != nil {
+		return err
+	}
+
+	locations, err := c.source.Locations()
+	if err != nil {
+		return err
+	}
+
+	relations, err := c.source.Relations()
+	if err != nil {
+		return err
+	}
+
+	// Relations already hold every date, so the dates endpoint is only used to cross-check
+	dates, err := c.source.Dates()
+	if err != nil {
+		log.Println("Dates unavailable, using relations only:", err)
+	}
+
+	snapshot := newCatalogSnapshot(artists, locations, relations, dates)
+	if len(snapshot.Conflicts) > 0 {
+		log.Printf("Catalog loaded with %d relation/dates conflicts\n", len(snapshot.Conflicts))
+	}
+
+	c.current.Store(snapshot)
+	for _, fn := range c.onLoad {
+		fn(snapshot)
+	}
+	return nil
+}
+
+// Snapshot returns the current snapshot without blocking (nil if never loaded)
+func (c *CatalogCache) Snapshot() *CatalogSnapshot {
+	return c.current.Load()
+}
+
+// Get returns the current snapshot, loading it on first use
+func (c *CatalogCache) Get() (*CatalogSnapshot, error) {
+	if snapshot := c.current.Load(); snapshot != nil {
+		return snapshot, nil
+	}
+
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+
+	// Another request may have loaded it while we were waiting
+	if snapshot := c.current.Load(); snapshot != nil {
+		return snapshot, nil
+	}
+
+	if err := c.load(); err != nil {
+		log.Println("Catalog load failed:", err)
+		return nil, ErrCatalogUnavailable
+	}
+	return c.current.Load(), nil
+}
+
+// Start loads the catalog once then refreshes it every interval until Stop is called
+func (c *CatalogCache) Start(interval time.Duration) {
+	if err := c.Load(); err != nil {
+		log.Println("Initial catalog load failed, will retry on demand:", err)
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	go c.refreshLoop(interval, c.stopChan())
+}
+
+// Stop ends the background refresh. The cache cannot be restarted afterwards.
+func (c *CatalogCache) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopChan())
+	})
+}
+
+func (c *CatalogCache) stopChan() chan struct{} {
+	c.stopInit.Do(func() {
+		c.stop = make(chan struct{})
+	})
+	return c.stop
+}
+
+func (c *CatalogCache) refreshLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Load(); err != nil {
+				log.Println("Catalog refresh failed, keeping previous snapshot:", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// newCatalogSnapshot builds a snapshot and its lookup tables
+func newCatalogSnapshot(artists []Artist, locations LocationResponse, relations RelationResponse, dates DatesResponse) *CatalogSnapshot {
+	snapshot := &CatalogSnapshot{
+		Artists:         artists,
+		Locations:       locations,
+		Relations:       relations,
+		Dates:           dates,
+		FetchedAt:       time.Now(),
+		artistsByID:     make(map[int]int, len(artists)),
+		artistLocations: make(map[int][]Location, len(relations.Index)),
+		artistConcerts:  make(map[int][]Concert, len(artists)),
+	}
+
+	for i, artist := range artists {
+		snapshot.artistsByID[artist.ID] = i
+	}
+
+	for _, relation := range relations.Index {
+		var artistLocations []Location
+		for location := range relation.DatesLocations {
+			cleanLocation := strings.TrimSpace(location)
+			if cleanLocation != "" {
+				artistLocations = append(artistLocations, ParseLocation(cleanLocation))
+			}
+		}
+		snapshot.artistLocations[relation.ID] = artistLocations
+	}
+
+	snapshot.Concerts, snapshot.Conflicts = BuildConcerts(locations, relations, dates)
+
+	// Concerts are sorted by artist, so each artist owns a contiguous range
+	start := 0
+	for i := range snapshot.Concerts {
+		if i+1 == len(snapshot.Concerts) || snapshot.Concerts[i+1].ArtistID != snapshot.Concerts[i].ArtistID {
+			artistID := snapshot.Concerts[i].ArtistID
+			snapshot.artistConcerts[artistID] = snapshot.Concerts[start : i+1 : i+1]
+			start = i + 1
+		}
+	}
+
+	return snapshot
+}
+
+// ArtistByID returns the artist with the given ID
+func (s *CatalogSnapshot) ArtistByID(id int) (Artist, bool) {
+	i, ok := s.artistsByID[id]
+	if !ok {
+		return Artist{}, false
+	}
+	return s.Artists[i], true
+}
+
+// ArtistWithLocations returns the artist with its concert locations and dates
+func (s *CatalogSnapshot) ArtistWithLocations(id int) (ArtistWithLocations, bool) {
+	artist, ok := s.ArtistByID(id)
+	if !ok {
+		return ArtistWithLocations{}, false
+	}
+
+	return ArtistWithLocations{
+		Artist:    artist,
+		Locations: joinArtistLocations(id, s.Locations, s.Relations),
+	}, true
+}
+
+// ArtistConcerts returns an artist's concerts sorted by date
+func (s *CatalogSnapshot) ArtistConcerts(id int) []Concert {
+	return s.artistConcerts[id]
+}
+
+// ConcertDateRange returns the dates of the first and last concerts (zero times if there are none)
+func (s *CatalogSnapshot) ConcertDateRange() (first, last time.Time) {
+	for _, concert := range s.Concerts {
+		if first.IsZero() || concert.Date.Before(first) {
+			first = concert.Date
+		}
+		if concert.Date.After(last) {
+			last = concert.Date
+		}
+	}
+	return first, last
+}
+
+// ArtistLocations returns the concert locations of every artist, keyed by artist ID
+func (s *CatalogSnapshot) ArtistLocations() map[int][]Location {
+	return s.artistLocations
+}
+
+// UniqueLocations returns every concert location once, sorted by country then name
+func (s *CatalogSnapshot) UniqueLocations() []Location {
+	bySlug := make(map[string]Location)
+	for _, locations := range s.artistLocations {
+		for _, location := range locations {
+			bySlug[location.Slug] = location
+		}
+	}
+
+	locations := make([]Location, 0, len(bySlug))
+	for _, location := range bySlug {
+		locations = append(locations, location)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Country != locations[j].Country {
+			return FoldText(locations[i].Country) < FoldText(locations[j].Country)
+		}
+		return FoldText(locations[i].Name()) < FoldText(locations[j].Name())
+	})
+	return locations
+}
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConcertDateLayout is the date format used by the API ("23-08-2019")
+const ConcertDateLayout = "02-01-2006"
+
+// Concert is one show of an artist
+type Concert struct {
+	ArtistID int       `json:"artist_id"`
+	Date     time.Time `json:"date"`
+	Location Location  `json:"location"`
+}
+
+// ConcertConflict reports a concert on which the relation and dates endpoints disagree
+type ConcertConflict struct {
+	ArtistID int    `json:"artist_id"`
+	Location string `json:"location"`
+	Date     string `json:"date"`
+	Reason   string `json:"reason"`
+}
+
+// Conflict reasons
+const (
+	ConflictMissingFromDates    = "missing from dates"
+	ConflictMissingFromRelation = "missing from relation"
+	ConflictUnalignedDates      = "dates do not match locations"
+	ConflictInvalidDate         = "invalid date"
+)
+
+// ParseConcertDate parses an API date, with or without the leading "*"
+func ParseConcertDate(raw string) (time.Time, error) {
+	return time.Parse(ConcertDateLayout, strings.TrimPrefix(strings.TrimSpace(raw), "*"))
+}
+
+// concertKey identifies a concert while merging both endpoints
+type concertKey struct {
+	artistID int
+	slug     string
+	date     string // ConcertDateLayout, without "*"
+}
+
+// BuildConcerts merges the relation and dates endpoints into a sorted list of concerts.
+// The relation endpoint is the reference; concerts only found in dates are kept too,
+// and every disagreement is returned as a conflict.
+func BuildConcerts(locations LocationResponse, relations RelationResponse, dates DatesResponse) ([]Concert, []ConcertConflict) {
+	var conflicts []ConcertConflict
+
+	fromRelation := make(map[concertKey]bool)
+	for _, relation := range relations.Index {
+		for slug, rawDates := range relation.DatesLocations {
+			for _, raw := range rawDates {
+				key := concertKey{artistID: relation.ID, slug: normalizeSlug(slug), date: strings.TrimPrefix(strings.TrimSpace(raw), "*")}
+				fromRelation[key] = true
+			}
+		}
+	}
+
+	fromDates := make(map[concertKey]bool)
+	aligned := make(map[int]bool) // Artists whose dates could be matched to locations
+	for _, entry := range dates.Index {
+		grouped, ok := groupDatesByLocation(entry, locations)
+		if !ok {
+			conflicts = append(conflicts, ConcertConflict{
+				ArtistID: entry.ID,
+				Reason:   ConflictUnalignedDates,
+			})
+			continue
+		}
+		aligned[entry.ID] = true
+		for slug, rawDates := range grouped {
+			for _, raw := range rawDates {
+				fromDates[concertKey{artistID: entry.ID, slug: slug, date: raw}] = true
+			}
+		}
+	}
+
+	// Union of both sources, reporting what only one of them knows
+	var concerts []Concert
+	addConcert := func(key concertKey) {
+		date, err := ParseConcertDate(key.date)
+		if err != nil {
+			conflicts = append(conflicts, ConcertConflict{ArtistID: key.artistID, Location: key.slug, Date: key.date, Reason: ConflictInvalidDate})
+			return
+		}
+		concerts = append(concerts, Concert{
+			ArtistID: key.artistID,
+			Date:     date,
+			Location: ParseLocation(key.slug),
+		})
+	}
+
+	for key := range fromRelation {
+		addConcert(key)
+		if aligned[key.artistID] && !fromDates[key] {
+			conflicts = append(conflicts, ConcertConflict{ArtistID: key.artistID, Location: key.slug, Date: key.date, Reason: ConflictMissingFromDates})
+		}
+	}
+	for key := range fromDates {
+		if !fromRelation[key] {
+			addConcert(key)
+			conflicts = append(conflicts, ConcertConflict{ArtistID: key.artistID, Location: key.slug, Date: key.date, Reason: ConflictMissingFromRelation})
+		}
+	}
+
+	SortConcerts(concerts)
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].ArtistID != conflicts[j].ArtistID {
+			return conflicts[i].ArtistID < conflicts[j].ArtistID
+		}
+		return conflicts[i].Location+conflicts[i].Date < conflicts[j].Location+conflicts[j].Date
+	})
+	return concerts, conflicts
+}
+
+// groupDatesByLocation splits an artist's dates into one group per location.
+// It fails when the number of groups does not match the artist's locations.
+func groupDatesByLocation(entry DatesData, locations LocationResponse) (map[string][]string, bool) {
+	var artistLocations []string
+	for _, locData := range locations.Index {
+		if locData.ID == entry.ID {
+			artistLocations = locData.Locations
+			break
+		}
+	}
+
+	var groups [][]string
+	for _, raw := range entry.Dates {
+		raw = strings.TrimSpace(raw)
+		if strings.HasPrefix(raw, "*") || len(groups) == 0 {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], strings.TrimPrefix(raw, "*"))
+	}
+
+	if len(groups) != len(artistLocations) {
+		return nil, false
+	}
+
+	grouped := make(map[string][]string, len(groups))
+	for i, group := range groups {
+		slug := normalizeSlug(artistLocations[i])
+		grouped[slug] = append(grouped[slug], group...)
+	}
+	return grouped, true
+}
+
+// SortConcerts orders concerts by artist, then date, then location
+func SortConcerts(concerts []Concert) {
+	sort.Slice(concerts, func(i, j int) bool {
+		a, b := concerts[i], concerts[j]
+		if a.ArtistID != b.ArtistID {
+			return a.ArtistID < b.ArtistID
+		}
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.Location.Slug < b.Location.Slug
+	})
+}
+
+// String returns a readable description, mostly for logs
+func (c ConcertConflict) String() string {
+	if c.Location == "" {
+		return fmt.Sprintf("artist %d: %s", c.ArtistID, c.Reason)
+	}
+	return fmt.Sprintf("artist %d, %s %s: %s", c.ArtistID, c.Location, c.Date, c.Reason)
+}
@@ -0,0 +1,105 @@
+package util
+
+import "strings"
+
+// Continent names, as shown to users
+const (
+	ContinentAfrica       = "Afrique"
+	ContinentNorthAmerica = "Amérique du Nord"
+	ContinentSouthAmerica = "Amérique du Sud"
+	ContinentAsia         = "Asie"
+	ContinentEurope       = "Europe"
+	ContinentOceania      = "Océanie"
+)
+
+// Continents lists the continents in display order
+var Continents = []string{
+	ContinentEurope,
+	ContinentNorthAmerica,
+	ContinentSouthAmerica,
+	ContinentAsia,
+	ContinentOceania,
+	ContinentAfrica,
+}
+
+// countryContinents maps a country display name (see countryName) to its continent.
+// Central America and the Caribbean belong to North America.
+var countryContinents = map[string]string{
+	// Europe
+	"Albania": ContinentEurope, "Andorra": ContinentEurope, "Austria": ContinentEurope,
+	"Belarus": ContinentEurope, "Belgium": ContinentEurope, "Bosnia and Herzegovina": ContinentEurope,
+	"Bulgaria": ContinentEurope, "Croatia": ContinentEurope, "Cyprus": ContinentEurope,
+	"Czech Republic": ContinentEurope, "Denmark": ContinentEurope, "Estonia": ContinentEurope,
+	"Finland": ContinentEurope, "France": ContinentEurope, "Germany": ContinentEurope,
+	"Greece": ContinentEurope, "Hungary": ContinentEurope, "Iceland": ContinentEurope,
+	"Ireland": ContinentEurope, "Italy": ContinentEurope, "Latvia": ContinentEurope,
+	"Lithuania": ContinentEurope, "Luxembourg": ContinentEurope, "Malta": ContinentEurope,
+	"Moldova": ContinentEurope, "Monaco": ContinentEurope, "Montenegro": ContinentEurope,
+	"Netherlands": ContinentEurope, "North Macedonia": ContinentEurope, "Norway": ContinentEurope,
+	"Poland": ContinentEurope, "Portugal": ContinentEurope, "Romania": ContinentEurope,
+	"Russia": ContinentEurope, "Serbia": ContinentEurope, "Slovakia": ContinentEurope,
+	"Slovenia": ContinentEurope, "Spain": ContinentEurope, "Sweden": ContinentEurope,
+	"Switzerland": ContinentEurope, "Turkey": ContinentEurope, "Ukraine": ContinentEurope,
+	"United Kingdom": ContinentEurope,
+
+	// North America, Central America and the Caribbean
+	"Canada": ContinentNorthAmerica, "Costa Rica": ContinentNorthAmerica, "Cuba": ContinentNorthAmerica,
+	"Curaçao": ContinentNorthAmerica, "Dominican Republic": ContinentNorthAmerica,
+	"El Salvador": ContinentNorthAmerica, "Guatemala": ContinentNorthAmerica,
+	"Honduras": ContinentNorthAmerica, "Jamaica": ContinentNorthAmerica, "Mexico": ContinentNorthAmerica,
+	"Netherlands Antilles": ContinentNorthAmerica, "Nicaragua": ContinentNorthAmerica,
+	"Panama": ContinentNorthAmerica, "Puerto Rico": ContinentNorthAmerica,
+	"United States": ContinentNorthAmerica,
+
+	// South America
+	"Argentina": ContinentSouthAmerica, "Bolivia": ContinentSouthAmerica, "Brazil": ContinentSouthAmerica,
+	"Chile": ContinentSouthAmerica, "Colombia": ContinentSouthAmerica, "Ecuador": ContinentSouthAmerica,
+	"Paraguay": ContinentSouthAmerica, "Peru": ContinentSouthAmerica, "Uruguay": ContinentSouthAmerica,
+	"Venezuela": ContinentSouthAmerica,
+
+	// Asia and the Middle East
+	"Bahrain": ContinentAsia, "Bangladesh": ContinentAsia, "Cambodia": ContinentAsia,
+	"China": ContinentAsia, "India": ContinentAsia, "Indonesia": ContinentAsia, "Israel": ContinentAsia,
+	"Japan": ContinentAsia, "Jordan": ContinentAsia, "Kazakhstan": ContinentAsia, "Kuwait": ContinentAsia,
+	"Lebanon": ContinentAsia, "Malaysia": ContinentAsia, "Mongolia": ContinentAsia, "Nepal": ContinentAsia,
+	"Oman": ContinentAsia, "Pakistan": ContinentAsia, "Philippines": ContinentAsia, "Qatar": ContinentAsia,
+	"Saudi Arabia": ContinentAsia, "Singapore": ContinentAsia, "South Korea": ContinentAsia,
+	"Sri Lanka": ContinentAsia, "Taiwan": ContinentAsia, "Thailand": ContinentAsia,
+	"United Arab Emirates": ContinentAsia, "Vietnam": ContinentAsia,
+
+	// Oceania
+	"Australia": ContinentOceania, "Fiji": ContinentOceania, "French Polynesia": ContinentOceania,
+	"New Caledonia": ContinentOceania, "New Zealand": ContinentOceania,
+
+	// Africa
+	"Algeria": ContinentAfrica, "Côte d'Ivoire": ContinentAfrica, "Egypt": ContinentAfrica,
+	"Ethiopia": ContinentAfrica, "Ghana": ContinentAfrica, "Kenya": ContinentAfrica,
+	"Morocco": ContinentAfrica, "Nigeria": ContinentAfrica, "Réunion": ContinentAfrica,
+	"Senegal": ContinentAfrica, "South Africa": ContinentAfrica, "Tunisia": ContinentAfrica,
+}
+
+// continentAliases lets users type a continent in English or without accents
+var continentAliases = map[string]string{
+	"africa":        ContinentAfrica,
+	"north america": ContinentNorthAmerica,
+	"south america": ContinentSouthAmerica,
+	"asia":          ContinentAsia,
+	"oceania":       ContinentOceania,
+}
+
+// ContinentOf returns the continent of a country display name ("" if unknown)
+func ContinentOf(country string) string {
+	return countryContinents[country]
+}
+
+// ContinentName returns the display name of a continent typed by a user
+// ("asie", "north_america", "Amérique du Nord"), or "" if it is not a continent
+func ContinentName(value string) string {
+	folded := strings.ReplaceAll(FoldText(strings.TrimSpace(value)), "_", " ")
+	for _, continent := range Continents {
+		if FoldText(continent) == folded {
+			return continent
+		}
+	}
+	return continentAliases[folded]
+}
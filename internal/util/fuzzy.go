@@ -0,0 +1,224 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchKind tells how a query matched a text, from worst to best
+type MatchKind int
+
+const (
+	MatchNone       MatchKind = iota
+	MatchFuzzy                // Within a few typos of a word
+	MatchSubstring            // Inside a word
+	MatchWordPrefix           // Start of a word other than the first
+	MatchPrefix               // Start of the text
+	MatchExact                // Whole text
+)
+
+// Span is a byte range of a text, [Start, End)
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// TextMatch is how a query matched one text
+type TextMatch struct {
+	Kind     MatchKind
+	Distance int  // Edit distance of a fuzzy match
+	Span     Span // Matched part of the original text
+}
+
+// Relevance scores: any name match but a fuzzy one ranks above any member match,
+// and every exact or partial match ranks above every fuzzy one
+var (
+	nameScores = map[MatchKind]int{
+		MatchExact: 1000, MatchPrefix: 900, MatchWordPrefix: 850, MatchSubstring: 800, MatchFuzzy: 400,
+	}
+	memberScores = map[MatchKind]int{
+		MatchExact: 700, MatchPrefix: 650, MatchWordPrefix: 620, MatchSubstring: 600, MatchFuzzy: 300,
+	}
+)
+
+// fuzzyPenalty is removed from a fuzzy score for each typo
+const fuzzyPenalty = 50
+
+// ArtistMatch is the best match of a query among an artist's name and members
+type ArtistMatch struct {
+	Score int
+	Field string // "name" or "member"
+	Text  string // The matched name or member
+	Span  Span   // Matched part of Text
+}
+
+// MatchArtist matches a query against an artist's name and members, ignoring case and accents
+// and tolerating typos. It reports false when nothing matches.
+func MatchArtist(artist Artist, query string) (ArtistMatch, bool) {
+	best := ArtistMatch{}
+	consider := func(field, text string, scores map[MatchKind]int) {
+		match := MatchText(text, query)
+		if score := scoreOf(match, scores); score > best.Score {
+			best = ArtistMatch{Score: score, Field: field, Text: text, Span: match.Span}
+		}
+	}
+
+	consider("name", artist.Name, nameScores)
+	for _, member := range artist.Members {
+		consider("member", member, memberScores)
+	}
+	return best, best.Score > 0
+}
+
+// NameScore ranks a match found in an artist name
+func NameScore(match TextMatch) int {
+	return scoreOf(match, nameScores)
+}
+
+// MemberScore ranks a match found in a member name
+func MemberScore(match TextMatch) int {
+	return scoreOf(match, memberScores)
+}
+
+func scoreOf(match TextMatch, scores map[MatchKind]int) int {
+	if match.Kind == MatchNone {
+		return 0
+	}
+	return scores[match.Kind] - fuzzyPenalty*match.Distance
+}
+
+// MatchText finds query in text, ignoring case and accents.
+// Without an exact occurrence, it looks for a word (or run of words) within MaxTypos of the query.
+func MatchText(text, query string) TextMatch {
+	q := strings.TrimSpace(FoldText(query))
+	if q == "" {
+		return TextMatch{}
+	}
+	folded, offsets := FoldTextOffsets(text)
+	span := func(start, end int) Span {
+		return Span{Start: offsets[start], End: offsets[end]}
+	}
+
+	// Exact occurrences, keeping the best placed one
+	best := TextMatch{}
+	for from := 0; from <= len(folded)-len(q); {
+		i := strings.Index(folded[from:], q)
+		if i < 0 {
+			break
+		}
+		i += from
+
+		kind := MatchSubstring
+		switch {
+		case i == 0 && len(q) == len(folded):
+			kind = MatchExact
+		case i == 0:
+			kind = MatchPrefix
+		case isWordStart(folded, i):
+			kind = MatchWordPrefix
+		}
+		if kind > best.Kind {
+			best = TextMatch{Kind: kind, Span: span(i, i+len(q))}
+		}
+		from = i + 1
+	}
+	if best.Kind != MatchNone {
+		return best
+	}
+
+	// Typos: compare the query with runs of as many words as it has
+	maxTypos := MaxTypos(q)
+	if maxTypos == 0 {
+		return TextMatch{}
+	}
+	words := wordBounds(folded)
+	n := len(wordBounds(q))
+	if n == 0 {
+		return TextMatch{}
+	}
+	for i := 0; i+n <= len(words); i++ {
+		start, end := words[i][0], words[i+n-1][1]
+		candidates := []int{end}
+		// The last word may be typed partially
+		if prefixEnd := start + len(q); prefixEnd < end && isRuneStart(folded, prefixEnd) {
+			candidates = append(candidates, prefixEnd)
+		}
+		for _, candidateEnd := range candidates {
+			distance := EditDistance(folded[start:candidateEnd], q)
+			if distance <= maxTypos && (best.Kind == MatchNone || distance < best.Distance) {
+				best = TextMatch{Kind: MatchFuzzy, Distance: distance, Span: span(start, candidateEnd)}
+			}
+		}
+	}
+	return best
+}
+
+// MaxTypos returns how many typos a folded query tolerates: none under 4 letters, 2 from 8
+func MaxTypos(query string) int {
+	switch n := len([]rune(query)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EditDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent letters turning a into b (optimal string alignment distance)
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rolling rows: two rows back is needed for swaps
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// wordBounds returns the [start, end) byte offsets of the words of s
+func wordBounds(s string) [][2]int {
+	var bounds [][2]int
+	start := -1
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			bounds = append(bounds, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		bounds = append(bounds, [2]int{start, len(s)})
+	}
+	return bounds
+}
+
+func isWordStart(s string, i int) bool {
+	before := []rune(s[:i])
+	r := before[len(before)-1]
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func isRuneStart(s string, i int) bool {
+	return i == len(s) || (s[i]&0xC0) != 0x80
+}
@@ -0,0 +1,247 @@
+package util
+
+import (
+	"strings"
+)
+
+// Location is a concert location parsed from an API slug such as "los_angeles-usa".
+// Slugs naming a state or province ("north_carolina-usa") have a Region but no City.
+type Location struct {
+	Slug      string `json:"slug"`
+	City      string `json:"city"`
+	Region    string `json:"region"`
+	Country   string `json:"country"`
+	Continent string `json:"continent"` // "" for a country missing from the continent table
+}
+
+// countryNames maps the country part of a slug to its display name
+// when simple title casing is not enough
+var countryNames = map[string]string{
+	"usa":                  "United States",
+	"us":                   "United States",
+	"uk":                   "United Kingdom",
+	"korea":                "South Korea",
+	"south_korea":          "South Korea",
+	"uae":                  "United Arab Emirates",
+	"united_arab_emirates": "United Arab Emirates",
+	"czech_republic":       "Czech Republic",
+	"czechia":              "Czech Republic",
+	"new_caledonia":        "New Caledonia",
+	"french_polynesia":     "French Polynesia",
+	"netherlands_antilles": "Netherlands Antilles",
+	"ivory_coast":          "Côte d'Ivoire",
+	"curacao":              "Curaçao",
+	"reunion":              "Réunion",
+}
+
+// placeNames restores accents and unusual casing of city names
+var placeNames = map[string]string{
+	"a_coruna":      "A Coruña",
+	"asuncion":      "Asunción",
+	"besancon":      "Besançon",
+	"bogota":        "Bogotá",
+	"brasilia":      "Brasília",
+	"cordoba":       "Córdoba",
+	"dusseldorf":    "Düsseldorf",
+	"florianopolis": "Florianópolis",
+	"gdansk":        "Gdańsk",
+	"geneve":        "Genève",
+	"goiania":       "Goiânia",
+	"goteborg":      "Göteborg",
+	"koln":          "Köln",
+	"krakow":        "Kraków",
+	"lodz":          "Łódź",
+	"malaga":        "Málaga",
+	"malmo":         "Malmö",
+	"medellin":      "Medellín",
+	"montreal":      "Montréal",
+	"munchen":       "München",
+	"nimes":         "Nîmes",
+	"noumea":        "Nouméa",
+	"nurnberg":      "Nürnberg",
+	"orebro":        "Örebro",
+	"orleans":       "Orléans",
+	"osnabruck":     "Osnabrück",
+	"plzen":         "Plzeň",
+	"poznan":        "Poznań",
+	"reykjavik":     "Reykjavík",
+	"saarbrucken":   "Saarbrücken",
+	"san_jose":      "San José",
+	"san_sebastian": "San Sebastián",
+	"sao_paulo":     "São Paulo",
+	"tromso":        "Tromsø",
+	"wroclaw":       "Wrocław",
+	"zurich":        "Zürich",
+}
+
+// regionNames lists, per country slug, the slug parts naming a state or province
+var regionNames = map[string]map[string]string{
+	"usa": {
+		"alabama": "Alabama", "alaska": "Alaska", "arizona": "Arizona", "arkansas": "Arkansas",
+		"california": "California", "colorado": "Colorado", "connecticut": "Connecticut",
+		"delaware": "Delaware", "florida": "Florida", "georgia": "Georgia", "hawaii": "Hawaii",
+		"idaho": "Idaho", "illinois": "Illinois", "indiana": "Indiana", "iowa": "Iowa",
+		"kansas": "Kansas", "kentucky": "Kentucky", "louisiana": "Louisiana", "maine": "Maine",
+		"maryland": "Maryland", "massachusetts": "Massachusetts", "michigan": "Michigan",
+		"minnesota": "Minnesota", "mississippi": "Mississippi", "missouri": "Missouri",
+		"montana": "Montana", "nebraska": "Nebraska", "nevada": "Nevada",
+		"new_hampshire": "New Hampshire", "new_jersey": "New Jersey", "new_mexico": "New Mexico",
+		"north_carolina": "North Carolina", "north_dakota": "North Dakota", "ohio": "Ohio",
+		"oklahoma": "Oklahoma", "oregon": "Oregon", "pennsylvania": "Pennsylvania",
+		"rhode_island": "Rhode Island", "south_carolina": "South Carolina",
+		"south_dakota": "South Dakota", "tennessee": "Tennessee", "texas": "Texas", "utah": "Utah",
+		"vermont": "Vermont", "virginia": "Virginia", "west_virginia": "West Virginia",
+		"wisconsin": "Wisconsin", "wyoming": "Wyoming",
+	},
+	"canada": {
+		"alberta": "Alberta", "british_columbia": "British Columbia", "manitoba": "Manitoba",
+		"new_brunswick": "New Brunswick", "newfoundland": "Newfoundland and Labrador",
+		"nova_scotia": "Nova Scotia", "ontario": "Ontario", "saskatchewan": "Saskatchewan",
+	},
+	"australia": {
+		"new_south_wales": "New South Wales", "queensland": "Queensland",
+		"south_australia": "South Australia", "tasmania": "Tasmania", "victoria": "Victoria",
+		"western_australia": "Western Australia",
+	},
+	"india": {
+		"maharashtra": "Maharashtra", "uttar_pradesh": "Uttar Pradesh", "karnataka": "Karnataka",
+	},
+}
+
+// locationOverrides fixes slugs that rules alone get wrong (ambiguous names, abbreviations)
+var locationOverrides = map[string]Location{
+	"del_mar-usa":          {City: "Del Mar", Region: "California", Country: "United States"},
+	"hong_kong-china":      {City: "Hong Kong", Country: "China"},
+	"los_angeles-usa":      {City: "Los Angeles", Region: "California", Country: "United States"},
+	"new_york-usa":         {City: "New York", Region: "New York", Country: "United States"},
+	"penrose-new_zealand":  {City: "Penrose", Region: "Auckland", Country: "New Zealand"},
+	"quebec-canada":        {City: "Québec", Region: "Québec", Country: "Canada"},
+	"saint_louis-usa":      {City: "St. Louis", Region: "Missouri", Country: "United States"},
+	"st_louis-usa":         {City: "St. Louis", Region: "Missouri", Country: "United States"},
+	"st_petersburg-russia": {City: "Saint Petersburg", Country: "Russia"},
+	"washington-usa":       {City: "Washington", Region: "District of Columbia", Country: "United States"},
+	"west_melbourne-usa":   {City: "West Melbourne", Region: "Florida", Country: "United States"},
+}
+
+// lowercaseWords stay lowercase inside a name ("Playa del Carmen", "Rio de Janeiro")
+var lowercaseWords = map[string]bool{
+	"de": true, "del": true, "da": true, "das": true, "do": true, "dos": true,
+	"des": true, "du": true, "di": true, "la": true, "le": true, "am": true, "an": true, "upon": true,
+}
+
+// ParseLocation turns an API slug ("north_carolina-usa") into a structured location
+func ParseLocation(slug string) Location {
+	slug = normalizeSlug(slug)
+
+	if override, ok := locationOverrides[slug]; ok {
+		override.Slug = slug
+		override.Continent = ContinentOf(override.Country)
+		return override
+	}
+
+	// The country is after the last dash, the place before it
+	place, country := slug, ""
+	if i := strings.LastIndex(slug, "-"); i >= 0 {
+		place, country = slug[:i], slug[i+1:]
+	}
+
+	location := Location{
+		Slug:    slug,
+		Country: countryName(country),
+	}
+	location.Continent = ContinentOf(location.Country)
+
+	if region, ok := regionNames[country][place]; ok {
+		location.Region = region
+	} else {
+		location.City = placeName(place)
+	}
+	return location
+}
+
+// Name returns the most precise place name (city, or region for state-level slugs)
+func (l Location) Name() string {
+	if l.City != "" {
+		return l.City
+	}
+	return l.Region
+}
+
+// String returns "City, Country" (or "Region, Country")
+func (l Location) String() string {
+	if l.Country == "" {
+		return l.Name()
+	}
+	return l.Name() + ", " + l.Country
+}
+
+// Query returns the most complete description, suited to geocoding
+func (l Location) Query() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Matches reports whether a user supplied value designates this location:
+// its slug, its display name, or its city, region or country alone.
+// The comparison ignores case and accents.
+func (l Location) Matches(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+	if strings.EqualFold(value, l.Slug) {
+		return true
+	}
+
+	folded := FoldText(value)
+	for _, candidate := range []string{l.String(), l.City, l.Region, l.Country} {
+		if candidate != "" && FoldText(candidate) == folded {
+			return true
+		}
+	}
+	return false
+}
+
+// CountryName returns the display name of a country typed by a user ("usa", "UK", "united states")
+func CountryName(value string) string {
+	return countryName(strings.ReplaceAll(FoldText(strings.TrimSpace(value)), " ", "_"))
+}
+
+func countryName(slug string) string {
+	if name, ok := countryNames[slug]; ok {
+		return name
+	}
+	return titleSlugPart(slug)
+}
+
+func placeName(slug string) string {
+	if name, ok := placeNames[slug]; ok {
+		return name
+	}
+	return titleSlugPart(slug)
+}
+
+// titleSlugPart turns "playa_del_carmen" into "Playa del Carmen"
+func titleSlugPart(part string) string {
+	words := strings.Fields(strings.ReplaceAll(part, "_", " "))
+	for i, word := range words {
+		if i > 0 && lowercaseWords[word] {
+			continue
+		}
+		if name, ok := placeNames[word]; ok {
+			words[i] = name
+			continue
+		}
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func normalizeSlug(slug string) string {
+	return strings.ToLower(strings.TrimSpace(slug))
+}
@@ -0,0 +1,172 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public Groupie Trackers API
+const DefaultBaseURL = "https://groupietrackers.herokuapp.com/api"
+
+// Upstream endpoints, also used as file names (<endpoint>.json) by FileSource
+const (
+	ArtistsEndpoint   = "artists"
+	LocationsEndpoint = "locations"
+	RelationEndpoint  = "relation"
+	DatesEndpoint     = "dates"
+)
+
+// Endpoints lists every upstream endpoint
+var Endpoints = []string{ArtistsEndpoint, LocationsEndpoint, RelationEndpoint, DatesEndpoint}
+
+// DataSource provides the artist data the site is built from
+type DataSource interface {
+	Artists() ([]Artist, error)
+	Locations() (LocationResponse, error)
+	Relations() (RelationResponse, error)
+	Dates() (DatesResponse, error)
+}
+
+// HTTPSource reads data from a Groupie Trackers compatible REST API
+type HTTPSource struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPSource creates an HTTP source (DefaultBaseURL if baseURL is empty)
+func NewHTTPSource(baseURL string) *HTTPSource {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &HTTPSource{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client: &http.Client{
+			Timeout: 10 * time.Second, // Timeout to avoid blocking
+		},
+	}
+}
+
+// URL returns the full URL of an endpoint
+func (s *HTTPSource) URL(endpoint string) string {
+	return s.BaseURL + "/" + endpoint
+}
+
+// Get performs a GET on an endpoint and checks the status code
+func (s *HTTPSource) Get(endpoint string) (*http.Response, error) {
+	resp, err := s.Client.Get(s.URL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %s", s.URL(endpoint), resp.Status)
+	}
+	return resp, nil
+}
+
+func (s *HTTPSource) getJSON(endpoint string, v interface{}) error {
+	resp, err := s.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s *HTTPSource) Artists() ([]Artist, error) {
+	var artists []Artist
+	if err := s.getJSON(ArtistsEndpoint, &artists); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
+
+func (s *HTTPSource) Locations() (LocationResponse, error) {
+	var locationResponse LocationResponse
+	if err := s.getJSON(LocationsEndpoint, &locationResponse); err != nil {
+		return LocationResponse{}, err
+	}
+	return locationResponse, nil
+}
+
+func (s *HTTPSource) Relations() (RelationResponse, error) {
+	var relationResponse RelationResponse
+	if err := s.getJSON(RelationEndpoint, &relationResponse); err != nil {
+		return RelationResponse{}, err
+	}
+	return relationResponse, nil
+}
+
+func (s *HTTPSource) Dates() (DatesResponse, error) {
+	var datesResponse DatesResponse
+	if err := s.getJSON(DatesEndpoint, &datesResponse); err != nil {
+		return DatesResponse{}, err
+	}
+	return datesResponse, nil
+}
+
+// FileSource reads a JSON snapshot of the API saved in a directory
+// (artists.json, locations.json, relation.json, dates.json)
+type FileSource struct {
+	Dir string
+}
+
+// NewFileSource creates a source reading from dir
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{Dir: dir}
+}
+
+// Path returns the file holding an endpoint's payload
+func (s *FileSource) Path(endpoint string) string {
+	return filepath.Join(s.Dir, endpoint+".json")
+}
+
+func (s *FileSource) readJSON(endpoint string, v interface{}) error {
+	file, err := os.Open(s.Path(endpoint))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(v)
+}
+
+func (s *FileSource) Artists() ([]Artist, error) {
+	var artists []Artist
+	if err := s.readJSON(ArtistsEndpoint, &artists); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
+
+func (s *FileSource) Locations() (LocationResponse, error) {
+	var locationResponse LocationResponse
+	if err := s.readJSON(LocationsEndpoint, &locationResponse); err != nil {
+		return LocationResponse{}, err
+	}
+	return locationResponse, nil
+}
+
+func (s *FileSource) Relations() (RelationResponse, error) {
+	var relationResponse RelationResponse
+	if err := s.readJSON(RelationEndpoint, &relationResponse); err != nil {
+		return RelationResponse{}, err
+	}
+	return relationResponse, nil
+}
+
+func (s *FileSource) Dates() (DatesResponse, error) {
+	var datesResponse DatesResponse
+	if err := s.readJSON(DatesEndpoint, &datesResponse); err != nil {
+		return DatesResponse{}, err
+	}
+	return datesResponse, nil
+}
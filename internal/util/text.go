@@ -0,0 +1,54 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldText lowercases s and strips diacritics so "Beyoncé" and "beyonce" compare equal
+func FoldText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		b.WriteString(foldRune(r))
+	}
+	return b.String()
+}
+
+// FoldTextOffsets is FoldText that also returns, for each byte of the folded text,
+// the offset in s of the rune it comes from, plus len(s) as a final entry.
+// It maps a match found in the folded text back to the original one.
+func FoldTextOffsets(s string) (string, []int) {
+	var b strings.Builder
+	b.Grow(len(s))
+	offsets := make([]int, 0, len(s)+1)
+
+	for i, r := range s {
+		folded := foldRune(r)
+		b.WriteString(folded)
+		for range len(folded) {
+			offsets = append(offsets, i)
+		}
+	}
+	return b.String(), append(offsets, len(s))
+}
+
+// foldRune returns the folded form of one rune: its canonical decomposition (NFD),
+// lowercased and without combining accents. It is "" for an accent alone.
+func foldRune(r rune) string {
+	if r < utf8.RuneSelf {
+		return string(unicode.ToLower(r))
+	}
+
+	var b strings.Builder
+	for _, d := range norm.NFD.String(string(r)) {
+		if !unicode.Is(unicode.Mn, d) {
+			b.WriteRune(unicode.ToLower(d))
+		}
+	}
+	return b.String()
+}
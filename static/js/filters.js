@@ -21,6 +21,10 @@ function init() {
 
     setupToggle('filterToggle', 'filterPanel', 'filterChevron');
     setupReset('resetFilters');
+    setupNearMe('nearMeButton', 'nearInput', 'nearLatInput', 'nearLonInput');
+    setupDecadeChips('.decade-chip');
+    setupSort('sortSelect', 'orderSelect');
+    setupLocationTree('locationTree');
 }
 
 function setupSlider(sliderId, inputId) {
@@ -106,6 +110,104 @@ function setupToggle(toggleId, panelId, chevronId) {
     });
 }
 
+// "Ma position" remplit les coordonnées, taper un lieu les efface
+function setupNearMe(buttonId, nearId, latId, lonId) {
+    const btn = document.getElementById(buttonId);
+    const near = document.getElementById(nearId);
+    const lat = document.getElementById(latId);
+    const lon = document.getElementById(lonId);
+    if (!btn || !near || !lat || !lon) return;
+
+    if (!navigator.geolocation) {
+        btn.disabled = true;
+        return;
+    }
+
+    near.addEventListener('input', () => {
+        lat.value = '';
+        lon.value = '';
+    });
+
+    btn.addEventListener('click', () => {
+        btn.disabled = true;
+        navigator.geolocation.getCurrentPosition((position) => {
+            lat.value = position.coords.latitude.toFixed(4);
+            lon.value = position.coords.longitude.toFixed(4);
+            near.value = '';
+            btn.form.submit();
+        }, () => {
+            btn.disabled = false;
+            alert('Position indisponible');
+        });
+    });
+}
+
+// Un clic sur une décennie règle les deux curseurs puis applique les filtres
+function setupDecadeChips(selector) {
+    document.querySelectorAll(selector).forEach((chip) => {
+        chip.addEventListener('click', () => {
+            const target = chip.dataset.target;
+            const bounds = { Min: chip.dataset.decadeMin, Max: chip.dataset.decadeMax };
+
+            for (const [suffix, value] of Object.entries(bounds)) {
+                const slider = document.getElementById(target + suffix);
+                const input = document.getElementById(target + suffix + 'Input');
+                if (slider) slider.value = value;
+                if (input) input.value = slider ? slider.value : value;
+            }
+            chip.form.submit();
+        });
+    });
+}
+
+// Changer de tri reprend son ordre par défaut et recharge la première page
+function setupSort(sortId, orderId) {
+    const sort = document.getElementById(sortId);
+    const order = document.getElementById(orderId);
+    if (!sort || !order) return;
+
+    const form = document.getElementById(sort.getAttribute('form'));
+    if (!form) return;
+
+    sort.addEventListener('change', () => {
+        const option = sort.options[sort.selectedIndex];
+        order.disabled = !sort.value;
+        order.value = option.dataset.desc === 'true' ? 'desc' : 'asc';
+        form.submit();
+    });
+
+    order.addEventListener('change', () => form.submit());
+}
+
+// Cocher un continent ou un pays coche (et verrouille) tout ce qu'il contient ;
+// les cases désactivées ne sont pas envoyées, seul le parent filtre
+function setupLocationTree(treeId) {
+    const tree = document.getElementById(treeId);
+    if (!tree) return;
+
+    // Ouvre les branches qui contiennent une sélection
+    tree.querySelectorAll('input:checked').forEach((input) => {
+        let node = input.closest('details');
+        if (input.parentElement.tagName === 'SUMMARY' && node) {
+            node = node.parentElement.closest('details');
+        }
+        while (node) {
+            node.open = true;
+            node = node.parentElement.closest('details');
+        }
+    });
+
+    tree.querySelectorAll('summary input[type="checkbox"]').forEach((parent) => {
+        parent.addEventListener('change', () => {
+            const children = parent.closest('details').querySelectorAll('.pl-6 input, .pl-8 input');
+            children.forEach((child) => {
+                child.checked = parent.checked;
+                child.disabled = parent.checked;
+            });
+        });
+    });
+}
+
 function setupReset(resetId) {
     const btn = document.getElementById(resetId);
     if (!btn) return;
@@ -1,3 +1,12 @@
+// Titres des groupes de suggestions, par type
+const SUGGESTION_GROUPS = {
+    'artiste': 'Artistes',
+    'membre': 'Membres',
+    'lieu': 'Lieux',
+    'album': 'Premiers albums',
+    'création': 'Créations'
+};
+
 // Variables globales
 let currentSuggestions = [];
 let selectedIndex = -1;
@@ -101,16 +110,20 @@ function displaySuggestions() {
     // Vider le container
     suggestionsContainer.innerHTML = '';
 
-    // Créer chaque suggestion
+    // Créer chaque suggestion, avec un titre au début de chaque groupe
     currentSuggestions.forEach((suggestion, index) => {
+        if (index === 0 || currentSuggestions[index - 1].type !== suggestion.type) {
+            const title = document.createElement('div');
+            title.className = 'suggestion-group';
+            title.textContent = SUGGESTION_GROUPS[suggestion.type] || suggestion.type;
+            suggestionsContainer.appendChild(title);
+        }
+
         const item = document.createElement('div');
         item.className = 'suggestion-item';
 
-        // Ajouter le contenu sans émoji
-        item.innerHTML = `
-            <span class="suggestion-text">${suggestion.text}</span>
-            <span class="suggestion-type">${suggestion.type}</span>
-        `;
+        // Le libellé commence par le texte trouvé, mis en surbrillance
+        item.appendChild(highlightText(suggestion.label, suggestion.match_start, suggestion.match_end));
 
         // Quand on clique sur une suggestion
         item.addEventListener('click', function() {
@@ -127,6 +140,22 @@ function displaySuggestions() {
     updateSelection();
 }
 
+// Construit le texte d'une suggestion avec la partie trouvée dans un <mark>
+function highlightText(text, start, end) {
+    const span = document.createElement('span');
+    span.className = 'suggestion-text';
+
+    if (!(start < end)) {
+        span.textContent = text;
+        return span;
+    }
+
+    const mark = document.createElement('mark');
+    mark.textContent = text.slice(start, end);
+    span.append(text.slice(0, start), mark, text.slice(end));
+    return span;
+}
+
 // Gestion des touches du clavier
 function handleKeydown(e) {
     // Si pas de suggestions visibles, ne rien faire
@@ -179,8 +208,8 @@ function selectSuggestion(index) {
     const suggestion = currentSuggestions[index];
 
     if (suggestion) {
-        // Rediriger vers la page de l'artiste
-        window.location.href = `/artist/${suggestion.artist_id}`;
+        // Rediriger vers la page de l'artiste, ou l'accueil filtré par lieu ou année
+        window.location.href = suggestion.url;
     }
 }
 
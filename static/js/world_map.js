@@ -0,0 +1,163 @@
+/**
+ * GROUPIE TRACKER - Carte mondiale des concerts
+ * Les marqueurs (et leurs regroupements) sont calculés côté Go pour le zoom courant
+ */
+
+'use strict';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+const form = document.getElementById('mapFilters');
+const fromSlider = document.getElementById('fromSlider');
+const toSlider = document.getElementById('toSlider');
+const fromInput = document.getElementById('fromInput');
+const toInput = document.getElementById('toInput');
+const fromLabel = document.getElementById('fromLabel');
+const toLabel = document.getElementById('toLabel');
+const statusEl = document.getElementById('mapStatus');
+
+const firstDate = parseISODate(form.dataset.firstDate);
+const lastDate = parseISODate(form.dataset.lastDate);
+const totalDays = Math.max(0, Math.round((lastDate - firstDate) / DAY_MS));
+
+const map = L.map('worldMap').setView([20, 0], 2);
+L.tileLayer('https://{s}.basemaps.cartocdn.com/dark_all/{z}/{x}/{y}{r}.png', {
+    attribution: '© OpenStreetMap contributors · © CARTO',
+    maxZoom: 19
+}).addTo(map);
+
+const markers = L.layerGroup().addTo(map);
+let requestId = 0;
+let debounceTimer = null;
+
+function parseISODate(value) {
+    return new Date(value + 'T00:00:00Z');
+}
+
+function dayOffset(value) {
+    const days = Math.round((parseISODate(value) - firstDate) / DAY_MS);
+    return Math.min(Math.max(days, 0), totalDays);
+}
+
+function formatISODate(date) {
+    return date.toISOString().slice(0, 10);
+}
+
+function escapeHTML(value) {
+    return String(value).replace(/[&<>"']/g, c => ({
+        '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'
+    }[c]));
+}
+
+// Curseurs de période : un jour par cran, le début ne dépasse jamais la fin
+function setupDateSliders() {
+    for (const slider of [fromSlider, toSlider]) {
+        slider.max = totalDays;
+    }
+    // Période reprise de l'URL (concert_from / concert_to), sinon tout l'historique
+    fromSlider.value = form.dataset.from ? dayOffset(form.dataset.from) : 0;
+    toSlider.value = form.dataset.to ? dayOffset(form.dataset.to) : totalDays;
+
+    fromSlider.addEventListener('input', () => {
+        if (Number(fromSlider.value) > Number(toSlider.value)) fromSlider.value = toSlider.value;
+        updateDateInputs();
+        scheduleRefresh();
+    });
+    toSlider.addEventListener('input', () => {
+        if (Number(toSlider.value) < Number(fromSlider.value)) toSlider.value = fromSlider.value;
+        updateDateInputs();
+        scheduleRefresh();
+    });
+    updateDateInputs();
+}
+
+function updateDateInputs() {
+    const from = new Date(firstDate.getTime() + Number(fromSlider.value) * DAY_MS);
+    const to = new Date(firstDate.getTime() + Number(toSlider.value) * DAY_MS);
+    fromInput.value = fromLabel.textContent = formatISODate(from);
+    toInput.value = toLabel.textContent = formatISODate(to);
+
+    for (const slider of [fromSlider, toSlider]) {
+        const percent = totalDays ? (Number(slider.value) / totalDays) * 100 : 0;
+        slider.style.setProperty('--slider-progress', percent + '%');
+    }
+}
+
+function scheduleRefresh() {
+    clearTimeout(debounceTimer);
+    debounceTimer = setTimeout(refresh, 250);
+}
+
+async function refresh() {
+    const params = new URLSearchParams(new FormData(form));
+    for (const [key, value] of [...params]) {
+        if (value === '') params.delete(key);
+    }
+    params.set('zoom', map.getZoom());
+
+    const current = ++requestId;
+    try {
+        const res = await fetch('/api/map/concerts?' + params.toString());
+        if (!res.ok) throw new Error('HTTP ' + res.status);
+        const collection = await res.json();
+        if (current !== requestId) return; // Une réponse plus récente est déjà affichée
+        render(collection);
+    } catch (error) {
+        console.error('Chargement de la carte impossible:', error);
+        statusEl.textContent = 'Impossible de charger les concerts.';
+    }
+}
+
+function render(collection) {
+    markers.clearLayers();
+    let concerts = 0;
+
+    for (const feature of collection.features) {
+        const [lon, lat] = feature.geometry.coordinates;
+        const p = feature.properties;
+        concerts += p.concerts;
+
+        if (p.cluster) {
+            const size = Math.min(60, 24 + Math.round(Math.sqrt(p.concerts) * 4));
+            const icon = L.divIcon({
+                html: String(p.concerts),
+                className: 'cluster-icon',
+                iconSize: [size, size]
+            });
+            L.marker([lat, lon], { icon })
+                .on('click', () => map.setView([lat, lon], Math.min(map.getZoom() + 2, map.getMaxZoom())))
+                .bindTooltip(p.locations + ' lieux · ' + p.artists.length + ' artistes')
+                .addTo(markers);
+            continue;
+        }
+
+        const artists = p.artist_ids
+            .map((id, i) => '<a href="/artist/' + id + '">' + escapeHTML(p.artists[i]) + '</a>')
+            .join(', ');
+        L.circleMarker([lat, lon], {
+            radius: 8,
+            color: '#fff',
+            weight: 2,
+            fillColor: '#00e5ff',
+            fillOpacity: p.approximate ? 0.2 : 0.8
+        })
+            .bindPopup('<b>' + escapeHTML(p.name) + '</b>'
+                + (p.approximate ? '<br><i>Position approximative</i>' : '')
+                + '<br>' + artists
+                + '<br>' + p.dates.join('<br>'))
+            .addTo(markers);
+    }
+
+    statusEl.textContent = concerts + ' concert' + (concerts > 1 ? 's' : '') + ' affiché' + (concerts > 1 ? 's' : '');
+}
+
+setupDateSliders();
+form.addEventListener('input', event => {
+    if (event.target !== fromSlider && event.target !== toSlider) scheduleRefresh();
+});
+form.addEventListener('submit', event => {
+    event.preventDefault();
+    refresh();
+});
+map.on('zoomend', refresh);
+refresh();